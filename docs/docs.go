@@ -15,9 +15,272 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/aliases": {
+            "get": {
+                "description": "Возвращает пользовательские псевдонимы монет, которые имеют приоритет над тикерами и названиями из справочника.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "aliases"
+                ],
+                "summary": "Список псевдонимов",
+                "responses": {
+                    "200": {
+                        "description": "Псевдонимы",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.CoinAlias"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Failed to fetch aliases",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Добавляет или заменяет псевдоним монеты (регистр не учитывается). Монета должна быть в справочнике CoinGecko.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "aliases"
+                ],
+                "summary": "Добавить псевдоним",
+                "parameters": [
+                    {
+                        "description": "Псевдоним и идентификатор монеты",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.CoinAlias"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "status: success",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "error: Invalid request body",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Неизвестная монета и близкие варианты",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnknownCoinResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "error: Failed to add alias",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/aliases/remove": {
+            "post": {
+                "description": "Удаляет пользовательский псевдоним монеты.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "aliases"
+                ],
+                "summary": "Удалить псевдоним",
+                "parameters": [
+                    {
+                        "description": "Запрос на удаление псевдонима",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.RemoveAliasRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "status: success",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "error: Invalid request body",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "error: Alias not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Failed to remove alias",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/backfill": {
+            "post": {
+                "description": "Создает задание загрузки истории цен монеты из CoinGecko за интервал [from, to] (unix-секунды, без to - до текущего момента). Интервал загружается страницами, уже сохраненные точки не дублируются, прерванное задание продолжается после перезапуска.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "backfill"
+                ],
+                "summary": "Загрузить историю цен",
+                "parameters": [
+                    {
+                        "description": "Запрос на загрузку истории",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.BackfillRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Задание создано",
+                        "schema": {
+                            "$ref": "#/definitions/types.BackfillJob"
+                        }
+                    },
+                    "400": {
+                        "description": "error: Invalid request body",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Failed to create backfill job",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/backfill/{id}": {
+            "get": {
+                "description": "Возвращает состояние задания загрузки истории: статус (pending, running, done, failed), время, до которого история загружена, и число сохраненных точек.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "backfill"
+                ],
+                "summary": "Состояние загрузки истории",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор задания",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Состояние задания",
+                        "schema": {
+                            "$ref": "#/definitions/types.BackfillJob"
+                        }
+                    },
+                    "400": {
+                        "description": "error: Invalid job id",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "error: Job not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Failed to fetch backfill job",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
         "/currency/add": {
             "post": {
-                "description": "Добавляет криптовалюту в список отслеживаемых (watched_currencies).",
+                "description": "Добавляет криптовалюту в список отслеживаемых (watched_currencies). Вместо идентификатора можно указать тикер, название или псевдоним. Идентификатор проверяется по справочнику монет CoinGecko; для неизвестного возвращается 422 с близкими вариантами. Необязательные interval (мс, не меньше 1000) или tier (уровень из FETCH_TIERS) задают частоту опроса монеты; повторное добавление обновляет расписание.",
                 "consumes": [
                     "application/json"
                 ],
@@ -50,7 +313,7 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "error: Invalid request body",
+                        "description": "error: Invalid request body | Invalid fetch schedule",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -58,6 +321,18 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "409": {
+                        "description": "Неоднозначный тикер или название и подходящие монеты",
+                        "schema": {
+                            "$ref": "#/definitions/types.AmbiguousCoinResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Неизвестная монета и близкие варианты",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnknownCoinResponse"
+                        }
+                    },
                     "500": {
                         "description": "error: Failed to add currency: \u003cdetails\u003e",
                         "schema": {
@@ -70,9 +345,64 @@ const docTemplate = `{
                 }
             }
         },
+        "/currency/candles": {
+            "post": {
+                "description": "Возвращает свечи OHLC монеты с указанной длительностью (interval: 30m, 1h, 4h, 1d, 1w) за интервал [from, to] (unix-секунды, без to - до текущего момента) в порядке времени открытия. Без source возвращаются свечи всех источников.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "currencies"
+                ],
+                "summary": "Получить свечи",
+                "parameters": [
+                    {
+                        "description": "Запрос на получение свечей",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.CandlesRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Свечи",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.Candle"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "error: Invalid request body",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Failed to fetch candles",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
         "/currency/price": {
             "post": {
-                "description": "Возвращает последнюю цену валюты (без timestamp) или ближайшую цену к указанному времени (с timestamp).",
+                "description": "Возвращает последнюю цену валюты (без timestamp) или ближайшую цену к указанному времени (с timestamp). Без source возвращается итоговая (консенсусная) цена, с source - котировка указанного поставщика. Валюта котировки задается полем quote (по умолчанию usd); если котировки в этой фиатной валюте не собираются, цена пересчитывается из usd по ближайшему курсу (fx_rate). Вместе с ценой возвращаются рыночная капитализация, объем и изменение за 24 часа, если источник их сообщил. Вместо идентификатора монеты можно указать тикер, название или псевдоним.",
                 "consumes": [
                     "application/json"
                 ],
@@ -119,6 +449,12 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "409": {
+                        "description": "Неоднозначный тикер или название и подходящие монеты",
+                        "schema": {
+                            "$ref": "#/definitions/types.AmbiguousCoinResponse"
+                        }
+                    },
                     "500": {
                         "description": "error: Failed to fetch price: \u003cdetails\u003e",
                         "schema": {
@@ -133,7 +469,7 @@ const docTemplate = `{
         },
         "/currency/remove": {
             "post": {
-                "description": "Удаляет криптовалюту из списка отслеживаемых (watched_currencies), сохраняя исторические цены в currency_prices.",
+                "description": "Удаляет криптовалюту из списка отслеживаемых (watched_currencies), сохраняя исторические цены в currency_prices. Вместо идентификатора можно указать тикер, название или псевдоним.",
                 "consumes": [
                     "application/json"
                 ],
@@ -174,6 +510,12 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "409": {
+                        "description": "Неоднозначный тикер или название и подходящие монеты",
+                        "schema": {
+                            "$ref": "#/definitions/types.AmbiguousCoinResponse"
+                        }
+                    },
                     "500": {
                         "description": "error: Failed to remove currency: \u003cdetails\u003e",
                         "schema": {
@@ -185,6 +527,29 @@ const docTemplate = `{
                     }
                 }
             }
+        },
+        "/providers/status": {
+            "get": {
+                "description": "Возвращает состояние автоматических выключателей поставщиков цен в порядке приоритета (closed - поставщик используется, open - временно отключен, half-open - выполняется пробный запрос).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "providers"
+                ],
+                "summary": "Состояние поставщиков цен",
+                "responses": {
+                    "200": {
+                        "description": "Состояние поставщиков",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.ProviderStatus"
+                            }
+                        }
+                    }
+                }
+            }
         }
     },
     "definitions": {
@@ -196,20 +561,245 @@ const docTemplate = `{
             "properties": {
                 "coin": {
                     "type": "string"
+                },
+                "interval": {
+                    "description": "Интервал опроса монеты в миллисекундах, имеет приоритет над tier",
+                    "type": "integer"
+                },
+                "tier": {
+                    "description": "Уровень приоритета из FETCH_TIERS; без interval и tier используется FETCH_INTERVAL",
+                    "type": "string"
+                }
+            }
+        },
+        "types.AmbiguousCoinResponse": {
+            "type": "object",
+            "properties": {
+                "candidates": {
+                    "description": "Монеты, подходящие под введенное значение",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.Coin"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.BackfillJob": {
+            "type": "object",
+            "properties": {
+                "coin": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "integer"
+                },
+                "cursor": {
+                    "description": "Время, до которого история уже загружена",
+                    "type": "integer"
+                },
+                "from": {
+                    "description": "Начало интервала, unix-секунды",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "quote": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "stored": {
+                    "description": "Сохранено новых точек",
+                    "type": "integer"
+                },
+                "to": {
+                    "description": "Конец интервала, unix-секунды",
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "integer"
+                }
+            }
+        },
+        "types.BackfillRequest": {
+            "type": "object",
+            "required": [
+                "coin",
+                "from"
+            ],
+            "properties": {
+                "coin": {
+                    "type": "string"
+                },
+                "from": {
+                    "description": "Начало интервала, unix-секунды",
+                    "type": "integer"
+                },
+                "quote": {
+                    "description": "Валюта котировки, по умолчанию usd",
+                    "type": "string"
+                },
+                "to": {
+                    "description": "Конец интервала, по умолчанию текущее время",
+                    "type": "integer"
+                }
+            }
+        },
+        "types.Candle": {
+            "type": "object",
+            "properties": {
+                "close": {
+                    "type": "number"
+                },
+                "coin": {
+                    "type": "string"
+                },
+                "high": {
+                    "type": "number"
+                },
+                "interval": {
+                    "description": "Длительность свечи: 1m, 1h, 4h, 1d",
+                    "type": "string"
+                },
+                "low": {
+                    "type": "number"
+                },
+                "open": {
+                    "type": "number"
+                },
+                "open_time": {
+                    "type": "integer"
+                },
+                "quote": {
+                    "description": "Валюта котировки",
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "volume": {
+                    "description": "Объем в валюте котировки",
+                    "type": "number"
+                }
+            }
+        },
+        "types.CandlesRequest": {
+            "type": "object",
+            "required": [
+                "coin",
+                "from",
+                "interval"
+            ],
+            "properties": {
+                "coin": {
+                    "type": "string"
+                },
+                "from": {
+                    "description": "Начало интервала, unix-секунды",
+                    "type": "integer"
+                },
+                "interval": {
+                    "description": "Длительность свечи",
+                    "type": "string"
+                },
+                "quote": {
+                    "description": "Валюта котировки, по умолчанию usd",
+                    "type": "string"
+                },
+                "source": {
+                    "description": "Источник свечей, по умолчанию любой",
+                    "type": "string"
+                },
+                "to": {
+                    "description": "Конец интервала, по умолчанию текущее время",
+                    "type": "integer"
+                }
+            }
+        },
+        "types.Coin": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "description": "Идентификатор CoinGecko",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "symbol": {
+                    "description": "Тикер",
+                    "type": "string"
+                }
+            }
+        },
+        "types.CoinAlias": {
+            "type": "object",
+            "required": [
+                "alias",
+                "coin"
+            ],
+            "properties": {
+                "alias": {
+                    "description": "Тикер, название или иное написание, регистр не учитывается",
+                    "type": "string"
+                },
+                "coin": {
+                    "description": "Идентификатор CoinGecko",
+                    "type": "string"
                 }
             }
         },
         "types.CurrencyPrice": {
             "type": "object",
             "properties": {
+                "change_24h": {
+                    "description": "Изменение цены за 24 часа, %",
+                    "type": "number"
+                },
                 "coin": {
                     "type": "string"
                 },
+                "confidence": {
+                    "description": "Достоверность цены от 0 до 1 для политики конфликтов highest_confidence",
+                    "type": "number"
+                },
+                "fx_rate": {
+                    "description": "Курс USD, по которому цена пересчитана в валюту котировки",
+                    "type": "number"
+                },
+                "market_cap": {
+                    "description": "Рыночная капитализация",
+                    "type": "number"
+                },
                 "price": {
                     "type": "number"
                 },
+                "quote": {
+                    "description": "Валюта котировки",
+                    "type": "string"
+                },
+                "raw": {
+                    "description": "Исходная котировка источника, участвовавшая в консенсусе",
+                    "type": "boolean"
+                },
+                "source": {
+                    "description": "Поставщик цены либо consensus",
+                    "type": "string"
+                },
                 "timestamp": {
                     "type": "integer"
+                },
+                "volume_24h": {
+                    "description": "Объем торгов за 24 часа",
+                    "type": "number"
                 }
             }
         },
@@ -222,10 +812,65 @@ const docTemplate = `{
                 "coin": {
                     "type": "string"
                 },
+                "quote": {
+                    "description": "Валюта котировки, по умолчанию usd",
+                    "type": "string"
+                },
+                "source": {
+                    "description": "Источник цены; по умолчанию итоговая цена (консенсусная или единственного источника)",
+                    "type": "string"
+                },
                 "timestamp": {
                     "type": "integer"
                 }
             }
+        },
+        "types.ProviderStatus": {
+            "type": "object",
+            "properties": {
+                "failures": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "opened_at": {
+                    "type": "integer"
+                },
+                "state": {
+                    "description": "closed, open или half-open",
+                    "type": "string"
+                }
+            }
+        },
+        "types.RemoveAliasRequest": {
+            "type": "object",
+            "required": [
+                "alias"
+            ],
+            "properties": {
+                "alias": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.UnknownCoinResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "suggestions": {
+                    "description": "Близкие по написанию идентификаторы",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
         }
     }
 }`
@@ -4,9 +4,11 @@ import (
 	"CryptoPriceCollection/internal/config"
 	"CryptoPriceCollection/internal/handlers"
 	"CryptoPriceCollection/internal/logger"
+	"CryptoPriceCollection/internal/providers"
 	"CryptoPriceCollection/internal/repositories"
 	"CryptoPriceCollection/internal/server"
 	"CryptoPriceCollection/internal/services"
+	"CryptoPriceCollection/internal/spool"
 	"CryptoPriceCollection/internal/system"
 	"CryptoPriceCollection/internal/types"
 	"context"
@@ -20,7 +22,8 @@ import (
 	"log"
 	"net/url"
 	"os"
-	"time"
+	"os/signal"
+	"syscall"
 )
 
 func Start() {
@@ -33,7 +36,15 @@ func Start() {
 	cfgConnDB := &types.ConfigConnDB{}
 	cfgHTTPServer := &types.ConfigHTTPServer{}
 	cfgAPIClient := &types.ConfigAPIClient{}
+	cfgProviders := &types.ConfigProviders{}
+	cfgConsensus := &types.ConfigConsensus{}
 	cfgTasks := &types.ConfigTasks{}
+	cfgAdaptive := &types.ConfigAdaptive{}
+	cfgSpool := &types.ConfigSpool{}
+	cfgBackfill := &types.ConfigBackfill{}
+	cfgCandles := &types.ConfigCandles{}
+	cfgCoins := &types.ConfigCoins{}
+	cfgFX := &types.ConfigFX{}
 
 	// Подгружаем конфигурацию из переменных окружения
 	err := config.GetConfigsPath([]any{
@@ -41,7 +52,15 @@ func Start() {
 		cfgConnDB,
 		cfgHTTPServer,
 		cfgAPIClient,
+		cfgProviders,
+		cfgConsensus,
 		cfgTasks,
+		cfgAdaptive,
+		cfgSpool,
+		cfgBackfill,
+		cfgCandles,
+		cfgCoins,
+		cfgFX,
 	})
 	if err != nil {
 		logCust.WriteLog(logrus.FatalLevel, "Get config in enviroment var", logrus.Fields{
@@ -57,7 +76,15 @@ func Start() {
 		ConnDB:     *cfgConnDB,
 		HTTPServer: *cfgHTTPServer,
 		APIClient:  *cfgAPIClient,
+		Providers:  *cfgProviders,
+		Consensus:  *cfgConsensus,
 		Tasks:      *cfgTasks,
+		Adaptive:   *cfgAdaptive,
+		Spool:      *cfgSpool,
+		Backfill:   *cfgBackfill,
+		Candles:    *cfgCandles,
+		Coins:      *cfgCoins,
+		FX:         *cfgFX,
 	}
 
 	// Устанавливаем формат логов как GELF
@@ -108,13 +135,86 @@ func Start() {
 	// Инициализация репозитория
 	repo := repositories.New(syst)
 
+	// Клиент CoinGecko общий для опроса цен и загрузки истории, чтобы соблюдать один лимит запросов
+	coinGecko := providers.NewCoinGecko(&cfgApp.Providers, &cfgApp.APIClient)
+
+	// Инициализация поставщиков цен
+	priceProviders, err := providers.New(&cfgApp.Providers, &cfgApp.APIClient, coinGecko)
+	if err != nil {
+		logCust.WriteLog(logrus.FatalLevel, "Create price providers", logrus.Fields{
+			"func":       "providers.New",
+			"error":      err,
+			"stacktrace": fmt.Sprintf("%+v", errors.WithStack(err)),
+		})
+	}
+
+	// Инициализация поставщиков потоковых котировок
+	priceStreamers, err := providers.NewStreamers(&cfgApp.Providers)
+	if err != nil {
+		logCust.WriteLog(logrus.FatalLevel, "Create price streamers", logrus.Fields{
+			"func":       "providers.NewStreamers",
+			"error":      err,
+			"stacktrace": fmt.Sprintf("%+v", errors.WithStack(err)),
+		})
+	}
+
+	// Инициализация поставщика свечей
+	candleProvider, err := providers.NewCandleProvider(&cfgApp.Providers, &cfgApp.Candles, &cfgApp.APIClient, coinGecko)
+	if err != nil {
+		logCust.WriteLog(logrus.FatalLevel, "Create candle provider", logrus.Fields{
+			"func":       "providers.NewCandleProvider",
+			"error":      err,
+			"stacktrace": fmt.Sprintf("%+v", errors.WithStack(err)),
+		})
+	}
+
+	// Инициализация поставщиков курсов фиатных валют
+	fxProviders, err := providers.NewFX(&cfgApp.FX, &cfgApp.APIClient)
+	if err != nil {
+		logCust.WriteLog(logrus.FatalLevel, "Create FX providers", logrus.Fields{
+			"func":       "providers.NewFX",
+			"error":      err,
+			"stacktrace": fmt.Sprintf("%+v", errors.WithStack(err)),
+		})
+	}
+
+	// Журнал цен на диске на время недоступности БД
+	var priceSpool *spool.Spool
+	if cfgApp.Spool.Dir != "" {
+		priceSpool, err = spool.Open(cfgApp.Spool.Dir, int64(cfgApp.Spool.SegmentSize)<<20)
+		if err != nil {
+			logCust.WriteLog(logrus.FatalLevel, "Open price spool", logrus.Fields{
+				"func":       "spool.Open",
+				"error":      err,
+				"stacktrace": fmt.Sprintf("%+v", errors.WithStack(err)),
+			})
+		}
+		defer priceSpool.Close()
+	}
+
 	// Инициализация сервиса
-	service := services.NewService(*repo, cfgAPIClient.BaseURL, time.Duration(cfgTasks.FetchInterval), time.Duration(cfgTasks.BatchInterval))
+	service := services.NewService(*repo, priceProviders, priceStreamers, coinGecko, candleProvider, coinGecko, fxProviders, priceSpool, &cfgApp.Providers, &cfgApp.Tasks, &cfgApp.Adaptive, &cfgApp.Consensus, &cfgApp.Backfill, &cfgApp.Candles, &cfgApp.Coins, &cfgApp.FX)
 
 	// Выборка цен в фоновом режиме
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
-	go service.CryptoService.StartPriceFetcher(ctx)
+	fetcherDone := make(chan struct{})
+	go func() {
+		defer close(fetcherDone)
+		service.CryptoService.StartPriceFetcher(ctx)
+	}()
+
+	// Обновление справочника монет в фоновом режиме
+	go service.CoinsService.Start(ctx)
+
+	// Получение курсов фиатных валют в фоновом режиме
+	go service.FXService.Start(ctx)
+
+	// Загрузка истории цен в фоновом режиме
+	go service.BackfillService.Start(ctx)
+
+	// Получение свечей в фоновом режиме
+	go service.CandlesService.Start(ctx)
 
 	// Инициализация ручек
 	handler := handlers.NewHandler(service)
@@ -124,7 +224,12 @@ func Start() {
 
 	// Инициализация и запуск сервера
 	s := server.NewServer(cfgApp, router)
-	if err := s.Start(ctx); err != nil {
+	err = s.Start(ctx)
+
+	// Дожидаемся записи накопленных цен перед выходом
+	cancel()
+	<-fetcherDone
+	if err != nil {
 		log.Fatalf("Server failed: %v", err)
 	}
 }
@@ -0,0 +1,89 @@
+package backfill
+
+import (
+	"CryptoPriceCollection/internal/services/backfill"
+	"CryptoPriceCollection/internal/types"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+type BackfillHandler interface {
+	CreateJobHandler(c *gin.Context)
+	GetJobHandler(c *gin.Context)
+}
+
+type backfillHandler struct {
+	service backfill.BackfillServiceInterface
+}
+
+func New(service backfill.BackfillServiceInterface) BackfillHandler {
+	return &backfillHandler{service: service}
+}
+
+// CreateJobHandler godoc
+// @Summary      Загрузить историю цен
+// @Description  Создает задание загрузки истории цен монеты из CoinGecko за интервал [from, to] (unix-секунды, без to - до текущего момента). Интервал загружается страницами, уже сохраненные точки не дублируются, прерванное задание продолжается после перезапуска.
+// @Tags         backfill
+// @Accept       json
+// @Produce      json
+// @Param        body body types.BackfillRequest true "Запрос на загрузку истории"
+// @Success      202 {object} types.BackfillJob "Задание создано"
+// @Failure      400 {object} map[string]string "error: Invalid request body"
+// @Failure      500 {object} map[string]string "error: Failed to create backfill job"
+// @Router       /backfill [post]
+func (h *backfillHandler) CreateJobHandler(c *gin.Context) {
+	var req types.BackfillRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	job, err := h.service.CreateJob(c.Request.Context(), req.Coin, req.Quote, req.From, req.To)
+	if errors.Is(err, backfill.ErrInvalidRange) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid range"})
+		return
+	}
+	if err != nil {
+		log.Printf("Ошибка создания задания загрузки истории для %s: %v", req.Coin, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create backfill job"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GetJobHandler godoc
+// @Summary      Состояние загрузки истории
+// @Description  Возвращает состояние задания загрузки истории: статус (pending, running, done, failed), время, до которого история загружена, и число сохраненных точек.
+// @Tags         backfill
+// @Produce      json
+// @Param        id path int true "Идентификатор задания"
+// @Success      200 {object} types.BackfillJob "Состояние задания"
+// @Failure      400 {object} map[string]string "error: Invalid job id"
+// @Failure      404 {object} map[string]string "error: Job not found"
+// @Failure      500 {object} map[string]string "error: Failed to fetch backfill job"
+// @Router       /backfill/{id} [get]
+func (h *backfillHandler) GetJobHandler(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job id"})
+		return
+	}
+
+	job, err := h.service.GetJob(c.Request.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Ошибка получения задания загрузки истории %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch backfill job"})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
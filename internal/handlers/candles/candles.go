@@ -0,0 +1,57 @@
+package candles
+
+import (
+	"CryptoPriceCollection/internal/services/candles"
+	"CryptoPriceCollection/internal/types"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+)
+
+type CandlesHandler interface {
+	GetCandlesHandler(c *gin.Context)
+}
+
+type candlesHandler struct {
+	service candles.CandlesServiceInterface
+}
+
+func New(service candles.CandlesServiceInterface) CandlesHandler {
+	return &candlesHandler{service: service}
+}
+
+// GetCandlesHandler godoc
+// @Summary      Получить свечи
+// @Description  Возвращает свечи OHLC монеты с указанной длительностью (interval: 30m, 1h, 4h, 1d, 1w) за интервал [from, to] (unix-секунды, без to - до текущего момента) в порядке времени открытия. Без source возвращаются свечи всех источников.
+// @Tags         currencies
+// @Accept       json
+// @Produce      json
+// @Param        body body types.CandlesRequest true "Запрос на получение свечей"
+// @Success      200 {array} types.Candle "Свечи"
+// @Failure      400 {object} map[string]string "error: Invalid request body"
+// @Failure      500 {object} map[string]string "error: Failed to fetch candles"
+// @Router       /currency/candles [post]
+func (h *candlesHandler) GetCandlesHandler(c *gin.Context) {
+	var req types.CandlesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	result, err := h.service.GetCandles(c.Request.Context(), req.Coin, req.Quote, req.Interval, req.Source, req.From, req.To)
+	if errors.Is(err, candles.ErrInvalidInterval) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid interval"})
+		return
+	}
+	if err != nil {
+		log.Printf("Ошибка получения свечей для %s: %v", req.Coin, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch candles"})
+		return
+	}
+	if result == nil {
+		result = []types.Candle{}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,116 @@
+package coins
+
+import (
+	"CryptoPriceCollection/internal/services/coins"
+	"CryptoPriceCollection/internal/types"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+)
+
+type CoinsHandler interface {
+	GetAliasesHandler(c *gin.Context)
+	AddAliasHandler(c *gin.Context)
+	RemoveAliasHandler(c *gin.Context)
+}
+
+type coinsHandler struct {
+	service coins.CoinsServiceInterface
+}
+
+func New(service coins.CoinsServiceInterface) CoinsHandler {
+	return &coinsHandler{service: service}
+}
+
+// GetAliasesHandler godoc
+// @Summary      Список псевдонимов
+// @Description  Возвращает пользовательские псевдонимы монет, которые имеют приоритет над тикерами и названиями из справочника.
+// @Tags         aliases
+// @Produce      json
+// @Success      200 {array} types.CoinAlias "Псевдонимы"
+// @Failure      500 {object} map[string]string "error: Failed to fetch aliases"
+// @Router       /aliases [get]
+func (h *coinsHandler) GetAliasesHandler(c *gin.Context) {
+	aliases, err := h.service.GetAliases(c.Request.Context())
+	if err != nil {
+		log.Printf("Ошибка получения псевдонимов: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch aliases"})
+		return
+	}
+	if aliases == nil {
+		aliases = []types.CoinAlias{}
+	}
+
+	c.JSON(http.StatusOK, aliases)
+}
+
+// AddAliasHandler godoc
+// @Summary      Добавить псевдоним
+// @Description  Добавляет или заменяет псевдоним монеты (регистр не учитывается). Монета должна быть в справочнике CoinGecko.
+// @Tags         aliases
+// @Accept       json
+// @Produce      json
+// @Param        body body types.CoinAlias true "Псевдоним и идентификатор монеты"
+// @Success      200 {object} map[string]string "status: success"
+// @Failure      400 {object} map[string]string "error: Invalid request body"
+// @Failure      422 {object} types.UnknownCoinResponse "Неизвестная монета и близкие варианты"
+// @Failure      500 {object} map[string]string "error: Failed to add alias"
+// @Router       /aliases [post]
+func (h *coinsHandler) AddAliasHandler(c *gin.Context) {
+	var req types.CoinAlias
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	err := h.service.AddAlias(c.Request.Context(), req.Alias, req.Coin)
+	var unknown *coins.UnknownCoinError
+	if errors.As(err, &unknown) {
+		c.JSON(http.StatusUnprocessableEntity, types.UnknownCoinResponse{
+			Error:       "Unknown coin",
+			Suggestions: unknown.Suggestions,
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Ошибка добавления псевдонима %s: %v", req.Alias, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add alias"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success"})
+}
+
+// RemoveAliasHandler godoc
+// @Summary      Удалить псевдоним
+// @Description  Удаляет пользовательский псевдоним монеты.
+// @Tags         aliases
+// @Accept       json
+// @Produce      json
+// @Param        body body types.RemoveAliasRequest true "Запрос на удаление псевдонима"
+// @Success      200 {object} map[string]string "status: success"
+// @Failure      400 {object} map[string]string "error: Invalid request body"
+// @Failure      404 {object} map[string]string "error: Alias not found"
+// @Failure      500 {object} map[string]string "error: Failed to remove alias"
+// @Router       /aliases/remove [post]
+func (h *coinsHandler) RemoveAliasHandler(c *gin.Context) {
+	var req types.RemoveAliasRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	err := h.service.RemoveAlias(c.Request.Context(), req.Alias)
+	if errors.Is(err, coins.ErrAliasNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Alias not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Ошибка удаления псевдонима %s: %v", req.Alias, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove alias"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success"})
+}
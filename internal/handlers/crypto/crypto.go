@@ -1,8 +1,10 @@
 package crypto
 
 import (
+	"CryptoPriceCollection/internal/services/coins"
 	"CryptoPriceCollection/internal/services/crypto"
 	"CryptoPriceCollection/internal/types"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5"
 	"log"
@@ -13,6 +15,7 @@ type CryptoHandler interface {
 	AddCurrencyHandler(c *gin.Context)
 	RemoveCurrencyHandler(c *gin.Context)
 	GetPriceHandler(c *gin.Context)
+	ProvidersStatusHandler(c *gin.Context)
 }
 
 type cryptoHandler struct {
@@ -25,13 +28,15 @@ func New(service crypto.CryptoServiceInterface) CryptoHandler {
 
 // AddCurrencyHandler godoc
 // @Summary      Добавить валюту
-// @Description  Добавляет криптовалюту в список отслеживаемых (watched_currencies).
+// @Description  Добавляет криптовалюту в список отслеживаемых (watched_currencies). Вместо идентификатора можно указать тикер, название или псевдоним. Идентификатор проверяется по справочнику монет CoinGecko; для неизвестного возвращается 422 с близкими вариантами. Необязательные interval (мс, не меньше 1000) или tier (уровень из FETCH_TIERS) задают частоту опроса монеты; повторное добавление обновляет расписание.
 // @Tags         currencies
 // @Accept       json
 // @Produce      json
 // @Param        body body types.AddCurrencyRequest true "Запрос на добавление валюты"
 // @Success      200 {object} map[string]string "status: success"
-// @Failure      400 {object} map[string]string "error: Invalid request body"
+// @Failure      400 {object} map[string]string "error: Invalid request body | Invalid fetch schedule"
+// @Failure      409 {object} types.AmbiguousCoinResponse "Неоднозначный тикер или название и подходящие монеты"
+// @Failure      422 {object} types.UnknownCoinResponse "Неизвестная монета и близкие варианты"
 // @Failure      500 {object} map[string]string "error: Failed to add currency: <details>"
 // @Router       /currency/add [post]
 func (h *cryptoHandler) AddCurrencyHandler(c *gin.Context) {
@@ -41,7 +46,23 @@ func (h *cryptoHandler) AddCurrencyHandler(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.AddCurrency(c.Request.Context(), req.Coin); err != nil {
+	err := h.service.AddCurrency(c.Request.Context(), req.Coin, req.Interval, req.Tier)
+	if errors.Is(err, crypto.ErrUnknownTier) || errors.Is(err, crypto.ErrInvalidInterval) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid fetch schedule"})
+		return
+	}
+	if ambiguousCoin(c, err) {
+		return
+	}
+	var unknown *coins.UnknownCoinError
+	if errors.As(err, &unknown) {
+		c.JSON(http.StatusUnprocessableEntity, types.UnknownCoinResponse{
+			Error:       "Unknown coin",
+			Suggestions: unknown.Suggestions,
+		})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add currency"})
 		return
 	}
@@ -51,13 +72,14 @@ func (h *cryptoHandler) AddCurrencyHandler(c *gin.Context) {
 
 // RemoveCurrencyHandler godoc
 // @Summary      Удалить валюту
-// @Description  Удаляет криптовалюту из списка отслеживаемых (watched_currencies), сохраняя исторические цены в currency_prices.
+// @Description  Удаляет криптовалюту из списка отслеживаемых (watched_currencies), сохраняя исторические цены в currency_prices. Вместо идентификатора можно указать тикер, название или псевдоним.
 // @Tags         currencies
 // @Accept       json
 // @Produce      json
 // @Param        body body types.AddCurrencyRequest true "Запрос на удаление валюты"
 // @Success      200 {object} map[string]string "status: success"
 // @Failure      400 {object} map[string]string "error: Invalid request body"
+// @Failure      409 {object} types.AmbiguousCoinResponse "Неоднозначный тикер или название и подходящие монеты"
 // @Failure      500 {object} map[string]string "error: Failed to remove currency: <details>"
 // @Router       /currency/remove [post]
 func (h *cryptoHandler) RemoveCurrencyHandler(c *gin.Context) {
@@ -67,7 +89,11 @@ func (h *cryptoHandler) RemoveCurrencyHandler(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.RemoveCurrency(c.Request.Context(), req.Coin); err != nil {
+	err := h.service.RemoveCurrency(c.Request.Context(), req.Coin)
+	if ambiguousCoin(c, err) {
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove currency"})
 		return
 	}
@@ -77,7 +103,7 @@ func (h *cryptoHandler) RemoveCurrencyHandler(c *gin.Context) {
 
 // GetPriceHandler godoc
 // @Summary      Получить цену валюты
-// @Description  Возвращает последнюю цену валюты (без timestamp) или ближайшую цену к указанному времени (с timestamp).
+// @Description  Возвращает последнюю цену валюты (без timestamp) или ближайшую цену к указанному времени (с timestamp). Без source возвращается итоговая (консенсусная) цена, с source - котировка указанного поставщика. Валюта котировки задается полем quote (по умолчанию usd); если котировки в этой фиатной валюте не собираются, цена пересчитывается из usd по ближайшему курсу (fx_rate). Вместе с ценой возвращаются рыночная капитализация, объем и изменение за 24 часа, если источник их сообщил. Вместо идентификатора монеты можно указать тикер, название или псевдоним.
 // @Tags         currencies
 // @Accept       json
 // @Produce      json
@@ -85,6 +111,7 @@ func (h *cryptoHandler) RemoveCurrencyHandler(c *gin.Context) {
 // @Success      200 {object} types.CurrencyPrice "Успешное получение цены"
 // @Failure      400 {object} map[string]string "error: Invalid request body"
 // @Failure      404 {object} map[string]string "error: Price not found"
+// @Failure      409 {object} types.AmbiguousCoinResponse "Неоднозначный тикер или название и подходящие монеты"
 // @Failure      500 {object} map[string]string "error: Failed to fetch price: <details>"
 // @Router       /currency/price [post]
 func (h *cryptoHandler) GetPriceHandler(c *gin.Context) {
@@ -94,8 +121,11 @@ func (h *cryptoHandler) GetPriceHandler(c *gin.Context) {
 		return
 	}
 
-	price, err := h.service.GetPrice(c.Request.Context(), req.Coin, req.Timestamp)
-	if err == pgx.ErrNoRows {
+	price, err := h.service.GetPrice(c.Request.Context(), req.Coin, req.Quote, req.Source, req.Timestamp)
+	if ambiguousCoin(c, err) {
+		return
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
 		log.Printf("Цена не найдена для %s с timestamp=%v", req.Coin, req.Timestamp)
 		c.JSON(http.StatusNotFound, gin.H{"error": "Price not found"})
 		return
@@ -108,3 +138,27 @@ func (h *cryptoHandler) GetPriceHandler(c *gin.Context) {
 
 	c.JSON(http.StatusOK, price)
 }
+
+// ProvidersStatusHandler godoc
+// @Summary      Состояние поставщиков цен
+// @Description  Возвращает состояние автоматических выключателей поставщиков цен в порядке приоритета (closed - поставщик используется, open - временно отключен, half-open - выполняется пробный запрос).
+// @Tags         providers
+// @Produce      json
+// @Success      200 {array} types.ProviderStatus "Состояние поставщиков"
+// @Router       /providers/status [get]
+func (h *cryptoHandler) ProvidersStatusHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, h.service.ProvidersStatus())
+}
+
+// ambiguousCoin отвечает 409 со списком кандидатов, если тикер или название подходит к нескольким монетам
+func ambiguousCoin(c *gin.Context, err error) bool {
+	var ambiguous *coins.AmbiguousCoinError
+	if !errors.As(err, &ambiguous) {
+		return false
+	}
+	c.JSON(http.StatusConflict, types.AmbiguousCoinResponse{
+		Error:      "Ambiguous coin",
+		Candidates: ambiguous.Candidates,
+	})
+	return true
+}
@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"CryptoPriceCollection/internal/handlers/backfill"
+	"CryptoPriceCollection/internal/handlers/candles"
+	"CryptoPriceCollection/internal/handlers/coins"
 	"CryptoPriceCollection/internal/handlers/crypto"
 	"CryptoPriceCollection/internal/services"
 	"github.com/gin-gonic/gin"
@@ -9,12 +12,18 @@ import (
 )
 
 type Handler struct {
-	crypto crypto.CryptoHandler
+	crypto   crypto.CryptoHandler
+	backfill backfill.BackfillHandler
+	candles  candles.CandlesHandler
+	coins    coins.CoinsHandler
 }
 
 func NewHandler(services *services.Service) *Handler {
 	return &Handler{
-		crypto: crypto.New(services.CryptoService),
+		crypto:   crypto.New(services.CryptoService),
+		backfill: backfill.New(services.BackfillService),
+		candles:  candles.New(services.CandlesService),
+		coins:    coins.New(services.CoinsService),
 	}
 }
 
@@ -24,6 +33,16 @@ func (h *Handler) InitRoutes() *gin.Engine {
 	router.POST("/currency/add", h.crypto.AddCurrencyHandler)
 	router.POST("/currency/remove", h.crypto.RemoveCurrencyHandler)
 	router.POST("/currency/price", h.crypto.GetPriceHandler)
+	router.POST("/currency/candles", h.candles.GetCandlesHandler)
+
+	router.GET("/providers/status", h.crypto.ProvidersStatusHandler)
+
+	router.GET("/aliases", h.coins.GetAliasesHandler)
+	router.POST("/aliases", h.coins.AddAliasHandler)
+	router.POST("/aliases/remove", h.coins.RemoveAliasHandler)
+
+	router.POST("/backfill", h.backfill.CreateJobHandler)
+	router.GET("/backfill/:id", h.backfill.GetJobHandler)
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
@@ -0,0 +1,299 @@
+package binance
+
+import (
+	"CryptoPriceCollection/internal/types"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Name название поставщика в конфигурации
+const Name = "binance"
+
+// DefaultBaseURL адрес REST API Binance по умолчанию
+const DefaultBaseURL = "https://api.binance.com"
+
+type Provider struct {
+	client  *http.Client
+	baseURL string
+	symbols map[string]string // id монеты -> символ Binance
+	now     func() time.Time  // время котировок, у которых биржа не сообщает свое
+}
+
+// tickerPrice ответ /api/v3/ticker/price
+type tickerPrice struct {
+	Symbol string `json:"symbol"`
+	Price  string `json:"price"`
+}
+
+// ticker24hr ответ /api/v3/ticker/24hr
+type ticker24hr struct {
+	Symbol             string `json:"symbol"`
+	PriceChangePercent string `json:"priceChangePercent"`
+	QuoteVolume        string `json:"quoteVolume"`
+	CloseTime          int64  `json:"closeTime"`
+}
+
+// usdSuffixes окончания символов, котируемых в USD и долларовых стейблкоинах (BUSD, FDUSD и т.п. оканчиваются на USD)
+var usdSuffixes = []string{"USD", "USDT", "USDC"}
+
+// apiError тело ошибки Binance
+type apiError struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+func New(baseURL string, symbols map[string]string, client *http.Client) *Provider {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Provider{
+		client:  client,
+		baseURL: baseURL,
+		symbols: symbols,
+		now:     time.Now,
+	}
+}
+
+// CheckSymbols проверяет, что все символы котируются в USD: цены Binance сохраняются с валютой котировки usd
+func CheckSymbols(symbols map[string]string) error {
+	coins := make([]string, 0, len(symbols))
+	for coin := range symbols {
+		coins = append(coins, coin)
+	}
+	sort.Strings(coins)
+	for _, coin := range coins {
+		symbol := strings.ToUpper(symbols[coin])
+		if !hasUSDSuffix(symbol) {
+			return fmt.Errorf("symbol %s for %s is not quoted in %s", symbols[coin], coin, types.DefaultQuote)
+		}
+	}
+	return nil
+}
+
+// hasUSDSuffix проверяет, что символ оканчивается валютой, приравниваемой к USD
+func hasUSDSuffix(symbol string) bool {
+	for _, suffix := range usdSuffixes {
+		if strings.HasSuffix(symbol, suffix) && len(symbol) > len(suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetClock задает источник текущего времени, например фиксированное время при воспроизведении записанных обменов
+func (p *Provider) SetClock(now func() time.Time) {
+	p.now = now
+}
+
+// Name возвращает название поставщика
+func (p *Provider) Name() string {
+	return Name
+}
+
+// FetchPrices получает цены через /api/v3/ticker/price и дополняет их данными /api/v3/ticker/24hr
+func (p *Provider) FetchPrices(ctx context.Context, coins []string) ([]types.Quote, error) {
+	bySymbol := make(map[string]string, len(coins))
+	symbols := make([]string, 0, len(coins))
+	for _, coin := range coins {
+		symbol, ok := p.symbols[coin]
+		if !ok {
+			log.Printf("No Binance symbol configured for %s", coin)
+			continue
+		}
+		bySymbol[symbol] = coin
+		symbols = append(symbols, symbol)
+	}
+	// Немапленные монеты - не сбой биржи, иначе автомат защиты отключил бы исправного поставщика
+	if len(symbols) == 0 {
+		log.Printf("No Binance symbols configured for requested coins %v", coins)
+		return nil, nil
+	}
+
+	var prices []tickerPrice
+	if err := p.get(ctx, "/api/v3/ticker/price", symbols, &prices); err != nil {
+		return nil, err
+	}
+
+	// Статистика за 24 часа не обязательна: без нее цена все равно полезна
+	stats := make(map[string]ticker24hr, len(symbols))
+	var daily []ticker24hr
+	if err := p.get(ctx, "/api/v3/ticker/24hr", symbols, &daily); err != nil {
+		log.Printf("Error fetching Binance 24hr statistics: %v", err)
+	}
+	for _, d := range daily {
+		stats[d.Symbol] = d
+	}
+
+	now := p.now().Unix()
+	quotes := make([]types.Quote, 0, len(prices))
+	for _, tp := range prices {
+		coin, ok := bySymbol[tp.Symbol]
+		if !ok {
+			continue
+		}
+		price, err := strconv.ParseFloat(tp.Price, 64)
+		if err != nil {
+			log.Printf("Error converting the Binance price for %s: %v", tp.Symbol, err)
+			continue
+		}
+		quote := types.Quote{
+			Coin:      coin,
+			Quote:     types.DefaultQuote,
+			Price:     price,
+			Timestamp: now,
+			Source:    Name,
+		}
+		if d, ok := stats[tp.Symbol]; ok {
+			if d.CloseTime > 0 {
+				quote.Timestamp = d.CloseTime / 1000
+			}
+			if change, err := strconv.ParseFloat(d.PriceChangePercent, 64); err == nil {
+				quote.Change24h = &change
+			}
+			if volume, err := strconv.ParseFloat(d.QuoteVolume, 64); err == nil {
+				quote.Volume24h = &volume
+			}
+		}
+		quotes = append(quotes, quote)
+	}
+	return quotes, nil
+}
+
+// FetchCandles получает свечи через /api/v3/klines за [from, to] (unix-секунды), постранично по 1000 свечей.
+// Интервал передается в формате Binance: 1m, 5m, 1h, 4h, 1d, 1w. Объем возвращается в валюте котировки.
+func (p *Provider) FetchCandles(ctx context.Context, coin, quote, interval string, from, to int64) ([]types.Candle, error) {
+	if quote != types.DefaultQuote {
+		return nil, fmt.Errorf("binance candles are only available in %s", types.DefaultQuote)
+	}
+	symbol, ok := p.symbols[coin]
+	if !ok {
+		return nil, fmt.Errorf("no Binance symbol configured for %s", coin)
+	}
+
+	var candles []types.Candle
+	start, end := from*1000, to*1000
+	for start <= end {
+		query := url.Values{}
+		query.Set("symbol", symbol)
+		query.Set("interval", interval)
+		query.Set("startTime", strconv.FormatInt(start, 10))
+		query.Set("endTime", strconv.FormatInt(end, 10))
+		query.Set("limit", strconv.Itoa(klinesLimit))
+
+		var klines [][]any
+		if err := p.getJSON(ctx, "/api/v3/klines", query, &klines); err != nil {
+			return nil, err
+		}
+		for _, kline := range klines {
+			candle, err := parseKline(kline)
+			if err != nil {
+				log.Printf("Error converting Binance kline for %s: %v", symbol, err)
+				continue
+			}
+			candle.Coin, candle.Quote, candle.Interval, candle.Source = coin, quote, interval, Name
+			candles = append(candles, candle)
+		}
+		if len(klines) < klinesLimit {
+			break
+		}
+		// Следующая страница начинается после времени закрытия последней свечи
+		closeTime, ok := klines[len(klines)-1][6].(float64)
+		if !ok {
+			break
+		}
+		start = int64(closeTime) + 1
+	}
+	return candles, nil
+}
+
+// klinesLimit максимум свечей в одном ответе /api/v3/klines
+const klinesLimit = 1000
+
+// parseKline разбирает свечу вида [openTime, open, high, low, close, volume, closeTime, quoteVolume, ...]
+func parseKline(kline []any) (types.Candle, error) {
+	if len(kline) < 8 {
+		return types.Candle{}, fmt.Errorf("unexpected kline length %d", len(kline))
+	}
+	openTime, ok := kline[0].(float64)
+	if !ok {
+		return types.Candle{}, fmt.Errorf("unexpected open time %v", kline[0])
+	}
+	var values [4]float64
+	for i := range values {
+		str, _ := kline[i+1].(string)
+		value, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return types.Candle{}, err
+		}
+		values[i] = value
+	}
+	candle := types.Candle{
+		OpenTime: int64(openTime) / 1000,
+		Open:     values[0],
+		High:     values[1],
+		Low:      values[2],
+		Close:    values[3],
+	}
+	if str, ok := kline[7].(string); ok {
+		if volume, err := strconv.ParseFloat(str, 64); err == nil {
+			candle.Volume = &volume
+		}
+	}
+	return candle, nil
+}
+
+// get выполняет GET запрос к эндпоинту тикеров с параметром symbols
+func (p *Provider) get(ctx context.Context, path string, symbols []string, out any) error {
+	encoded, err := json.Marshal(symbols)
+	if err != nil {
+		return fmt.Errorf("encoding Binance symbols: %w", err)
+	}
+	query := url.Values{}
+	query.Set("symbols", string(encoded))
+	return p.getJSON(ctx, path, query, out)
+}
+
+// getJSON выполняет GET запрос к API Binance и декодирует ответ в out
+func (p *Provider) getJSON(ctx context.Context, path string, query url.Values, out any) error {
+	endpoint := p.baseURL + path + "?" + query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("creating request to Binance: %w", err)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("HTTP request error to Binance: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading the Binance response: %w", err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusTeapot:
+		// 418 Binance возвращает при блокировке IP за игнорирование 429
+		return fmt.Errorf("binance rate limit exceeded (status %d, retry after %q)", resp.StatusCode, resp.Header.Get("Retry-After"))
+	case resp.StatusCode != http.StatusOK:
+		var apiErr apiError
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Msg != "" {
+			return fmt.Errorf("binance error %d: %s (status %d)", apiErr.Code, apiErr.Msg, resp.StatusCode)
+		}
+		return fmt.Errorf("unexpected Binance status %d", resp.StatusCode)
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("decoding error JSON: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+var testSymbols = map[string]string{"bitcoin": "BTCUSDT", "ethereum": "ETHUSDT"}
+
+// newRESTStandIn локальная замена REST API Binance с обработчиками по пути запроса
+func newRESTStandIn(t *testing.T, routes map[string]http.HandlerFunc) (*Provider, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		handler, ok := routes[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return New(server.URL, testSymbols, server.Client()), &requests
+}
+
+func respond(status int, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}
+}
+
+func TestFetchPrices(t *testing.T) {
+	const prices = `[{"symbol":"BTCUSDT","price":"65000.5"},{"symbol":"ETHUSDT","price":"3000"}]`
+	const daily = `[{"symbol":"BTCUSDT","priceChangePercent":"-1.5","quoteVolume":"123456.7","closeTime":1754645360999}]`
+
+	tests := []struct {
+		name     string
+		coins    []string
+		routes   map[string]http.HandlerFunc
+		want     map[string]float64
+		wantErr  string
+		requests int32
+	}{
+		{
+			name:  "prices with 24hr statistics",
+			coins: []string{"bitcoin", "ethereum"},
+			routes: map[string]http.HandlerFunc{
+				"/api/v3/ticker/price": respond(http.StatusOK, prices),
+				"/api/v3/ticker/24hr":  respond(http.StatusOK, daily),
+			},
+			want:     map[string]float64{"bitcoin": 65000.5, "ethereum": 3000},
+			requests: 2,
+		},
+		{
+			name:  "24hr statistics are optional",
+			coins: []string{"bitcoin", "ethereum"},
+			routes: map[string]http.HandlerFunc{
+				"/api/v3/ticker/price": respond(http.StatusOK, prices),
+				"/api/v3/ticker/24hr":  respond(http.StatusInternalServerError, ""),
+			},
+			want:     map[string]float64{"bitcoin": 65000.5, "ethereum": 3000},
+			requests: 2,
+		},
+		{
+			name:  "unmapped coin is skipped",
+			coins: []string{"bitcoin", "dogecoin"},
+			routes: map[string]http.HandlerFunc{
+				"/api/v3/ticker/price": respond(http.StatusOK, `[{"symbol":"BTCUSDT","price":"65000.5"}]`),
+				"/api/v3/ticker/24hr":  respond(http.StatusOK, `[]`),
+			},
+			want:     map[string]float64{"bitcoin": 65000.5},
+			requests: 2,
+		},
+		{
+			name:     "no mapped coins is not an error",
+			coins:    []string{"dogecoin"},
+			want:     map[string]float64{},
+			requests: 0,
+		},
+		{
+			name:  "rate limit",
+			coins: []string{"bitcoin"},
+			routes: map[string]http.HandlerFunc{
+				"/api/v3/ticker/price": respond(http.StatusTooManyRequests, ""),
+			},
+			wantErr:  "rate limit",
+			requests: 1,
+		},
+		{
+			name:  "ip ban",
+			coins: []string{"bitcoin"},
+			routes: map[string]http.HandlerFunc{
+				"/api/v3/ticker/price": respond(http.StatusTeapot, ""),
+			},
+			wantErr:  "rate limit",
+			requests: 1,
+		},
+		{
+			name:  "api error",
+			coins: []string{"bitcoin"},
+			routes: map[string]http.HandlerFunc{
+				"/api/v3/ticker/price": respond(http.StatusBadRequest, `{"code":-1121,"msg":"Invalid symbol."}`),
+			},
+			wantErr:  "Invalid symbol.",
+			requests: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, requests := newRESTStandIn(t, tt.routes)
+			quotes, err := provider.FetchPrices(context.Background(), tt.coins)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := requests.Load(); got != tt.requests {
+				t.Fatalf("requests = %d, want %d", got, tt.requests)
+			}
+			if tt.wantErr != "" {
+				return
+			}
+
+			got := make(map[string]float64, len(quotes))
+			for _, quote := range quotes {
+				if quote.Source != Name || quote.Quote != "usd" {
+					t.Fatalf("unexpected quote %+v", quote)
+				}
+				got[quote.Coin] = quote.Price
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("quotes = %v, want %v", got, tt.want)
+			}
+			for coin, price := range tt.want {
+				if got[coin] != price {
+					t.Fatalf("price of %s = %v, want %v", coin, got[coin], price)
+				}
+			}
+		})
+	}
+}
+
+func TestFetchPricesUsesDailyStatistics(t *testing.T) {
+	var symbols []string
+	provider, _ := newRESTStandIn(t, map[string]http.HandlerFunc{
+		"/api/v3/ticker/price": func(w http.ResponseWriter, r *http.Request) {
+			if err := json.Unmarshal([]byte(r.URL.Query().Get("symbols")), &symbols); err != nil {
+				t.Errorf("symbols parameter %q: %v", r.URL.Query().Get("symbols"), err)
+			}
+			_, _ = w.Write([]byte(`[{"symbol":"BTCUSDT","price":"65000.5"}]`))
+		},
+		"/api/v3/ticker/24hr": respond(http.StatusOK, `[{"symbol":"BTCUSDT","priceChangePercent":"-1.5","quoteVolume":"123456.7","closeTime":1754645360999}]`),
+	})
+
+	quotes, err := provider.FetchPrices(context.Background(), []string{"ethereum", "bitcoin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(symbols)
+	if strings.Join(symbols, ",") != "BTCUSDT,ETHUSDT" {
+		t.Fatalf("requested symbols = %v", symbols)
+	}
+	if len(quotes) != 1 {
+		t.Fatalf("quotes = %+v", quotes)
+	}
+	quote := quotes[0]
+	if quote.Timestamp != 1754645360 {
+		t.Fatalf("timestamp = %d, want close time", quote.Timestamp)
+	}
+	if quote.Change24h == nil || *quote.Change24h != -1.5 {
+		t.Fatalf("change_24h = %v", quote.Change24h)
+	}
+	if quote.Volume24h == nil || *quote.Volume24h != 123456.7 {
+		t.Fatalf("volume_24h = %v", quote.Volume24h)
+	}
+}
+
+func TestFetchCandles(t *testing.T) {
+	provider, _ := newRESTStandIn(t, map[string]http.HandlerFunc{
+		"/api/v3/klines": func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+			if q.Get("symbol") != "BTCUSDT" || q.Get("interval") != "1h" || q.Get("startTime") != "1754640000000" {
+				t.Errorf("unexpected query %s", r.URL.RawQuery)
+			}
+			_, _ = w.Write([]byte(`[
+				[1754640000000,"100","110","90","105","12.5",1754643599999,"1300.5",10,"6","600","0"],
+				[1754643600000,"105","106","104","105.5","1",1754647199999,"105",1,"0","0","0"]
+			]`))
+		},
+	})
+
+	candles, err := provider.FetchCandles(context.Background(), "bitcoin", "usd", "1h", 1754640000, 1754647199)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candles) != 2 {
+		t.Fatalf("candles = %+v", candles)
+	}
+	c := candles[0]
+	if c.Coin != "bitcoin" || c.Interval != "1h" || c.Source != Name || c.OpenTime != 1754640000 ||
+		c.Open != 100 || c.High != 110 || c.Low != 90 || c.Close != 105 || c.Volume == nil || *c.Volume != 1300.5 {
+		t.Fatalf("unexpected candle %+v", c)
+	}
+
+	if _, err := provider.FetchCandles(context.Background(), "bitcoin", "eur", "1h", 0, 1); err == nil {
+		t.Fatal("expected error for non-usd quote")
+	}
+}
+
+func TestCheckSymbols(t *testing.T) {
+	tests := []struct {
+		symbols map[string]string
+		wantErr bool
+	}{
+		{symbols: map[string]string{"bitcoin": "BTCUSDT", "ethereum": "ETHUSDC"}},
+		{symbols: map[string]string{"bitcoin": "BTCFDUSD", "ethereum": "ethusdt"}},
+		{symbols: map[string]string{"bitcoin": "BTCEUR"}, wantErr: true},
+		{symbols: map[string]string{"ethereum": "ETHBTC"}, wantErr: true},
+		{symbols: map[string]string{"tether": "USDT"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := CheckSymbols(tt.symbols); (err != nil) != tt.wantErr {
+			t.Errorf("CheckSymbols(%v) error = %v, wantErr %v", tt.symbols, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package binance
+
+import (
+	"CryptoPriceCollection/internal/types"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// DefaultStreamURL адрес WebSocket API Binance по умолчанию
+const DefaultStreamURL = "wss://stream.binance.com:9443/ws"
+
+const (
+	minReconnectBackoff = time.Second
+	maxReconnectBackoff = time.Minute
+	// Раз в pingInterval серверу отправляется LIST_SUBSCRIPTIONS: ответ на него - обычное сообщение, поэтому
+	// даже без тиков соединение подтверждает, что живо. Понги WebSocket пакет x/net/websocket не отдает наружу.
+	defaultPingInterval = 30 * time.Second
+	// Если за readTimeout не пришло ни одного сообщения, соединение считается оборванным (полуоткрытый TCP)
+	defaultReadTimeout = 90 * time.Second
+)
+
+type Streamer struct {
+	url     string
+	symbols map[string]string // id монеты -> символ Binance
+	coins   map[string]string // символ Binance -> id монеты
+	nextID  int64
+
+	pingInterval time.Duration
+	readTimeout  time.Duration
+}
+
+// miniTicker событие потока <symbol>@miniTicker
+type miniTicker struct {
+	Event       string `json:"e"`
+	EventTime   int64  `json:"E"`
+	Symbol      string `json:"s"`
+	Close       string `json:"c"`
+	Open        string `json:"o"`
+	QuoteVolume string `json:"q"`
+}
+
+// streamMessage сообщение WebSocket: событие тикера либо ответ на команду
+type streamMessage struct {
+	miniTicker
+	ID    *int64    `json:"id"`
+	Error *apiError `json:"error"`
+}
+
+// streamCommand команда подписки/отписки
+type streamCommand struct {
+	Method string   `json:"method"`
+	Params []string `json:"params,omitempty"`
+	ID     int64    `json:"id"`
+}
+
+func NewStreamer(streamURL string, symbols map[string]string) *Streamer {
+	if streamURL == "" {
+		streamURL = DefaultStreamURL
+	}
+	coins := make(map[string]string, len(symbols))
+	for coin, symbol := range symbols {
+		coins[strings.ToUpper(symbol)] = coin
+	}
+	return &Streamer{
+		url:          streamURL,
+		symbols:      symbols,
+		coins:        coins,
+		pingInterval: defaultPingInterval,
+		readTimeout:  defaultReadTimeout,
+	}
+}
+
+// Name возвращает название поставщика
+func (s *Streamer) Name() string {
+	return Name
+}
+
+// StreamPrices подписывается на miniTicker по каждой монете из списка наблюдения и пишет котировки в out.
+// При изменении списка подписки обновляются, при обрыве соединение восстанавливается с нарастающей паузой.
+func (s *Streamer) StreamPrices(ctx context.Context, watchlist <-chan []string, out chan<- types.Quote) error {
+	var streams map[string]struct{}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case coins := <-watchlist:
+		streams = s.streamsFor(coins)
+	}
+
+	backoff := minReconnectBackoff
+	for {
+		conn, err := s.dial(ctx)
+		if err != nil {
+			log.Printf("Error connecting to Binance stream: %v", err)
+		} else {
+			started := time.Now()
+			streams, err = s.session(ctx, conn, streams, watchlist, out)
+			conn.Close()
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Printf("Binance stream disconnected: %v", err)
+			if time.Since(started) > maxReconnectBackoff {
+				backoff = minReconnectBackoff
+			}
+		}
+
+		// Пауза с джиттером, чтобы не переподключаться синхронно с другими клиентами
+		wait := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		log.Printf("Reconnecting to Binance stream in %s", wait)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case coins := <-watchlist:
+			timer.Stop()
+			streams = s.streamsFor(coins)
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, maxReconnectBackoff)
+	}
+}
+
+// session обслуживает одно соединение до его обрыва и возвращает актуальный набор подписок
+func (s *Streamer) session(ctx context.Context, conn *websocket.Conn, streams map[string]struct{}, watchlist <-chan []string, out chan<- types.Quote) (map[string]struct{}, error) {
+	if err := s.send(conn, "SUBSCRIBE", keys(streams)); err != nil {
+		return streams, err
+	}
+
+	readErr := make(chan error, 1)
+	ticks := make(chan types.Quote)
+	done := make(chan struct{})
+	defer close(done)
+	go s.read(conn, ticks, readErr, done)
+
+	ping := time.NewTicker(s.pingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return streams, ctx.Err()
+		case err := <-readErr:
+			return streams, err
+		case <-ping.C:
+			if err := s.sendCommand(conn, "LIST_SUBSCRIPTIONS", nil); err != nil {
+				return streams, err
+			}
+		case quote := <-ticks:
+			select {
+			case out <- quote:
+			case <-ctx.Done():
+				return streams, ctx.Err()
+			}
+		case coins := <-watchlist:
+			next := s.streamsFor(coins)
+			var added, removed []string
+			for stream := range next {
+				if _, ok := streams[stream]; !ok {
+					added = append(added, stream)
+				}
+			}
+			for stream := range streams {
+				if _, ok := next[stream]; !ok {
+					removed = append(removed, stream)
+				}
+			}
+			streams = next
+			if err := s.send(conn, "UNSUBSCRIBE", removed); err != nil {
+				return streams, err
+			}
+			if err := s.send(conn, "SUBSCRIBE", added); err != nil {
+				return streams, err
+			}
+		}
+	}
+}
+
+// read читает сообщения из соединения, пока оно не закроется или не замолчит дольше readTimeout
+func (s *Streamer) read(conn *websocket.Conn, ticks chan<- types.Quote, readErr chan<- error, done <-chan struct{}) {
+	for {
+		if err := conn.SetReadDeadline(time.Now().Add(s.readTimeout)); err != nil {
+			readErr <- err
+			return
+		}
+		var data []byte
+		if err := websocket.Message.Receive(conn, &data); err != nil {
+			readErr <- err
+			return
+		}
+
+		var msg streamMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			log.Printf("Error decoding Binance stream message: %v", err)
+			continue
+		}
+		if msg.Error != nil {
+			log.Printf("Binance stream command %v failed: %d %s", msg.ID, msg.Error.Code, msg.Error.Msg)
+			continue
+		}
+		if msg.Event != "24hrMiniTicker" {
+			continue
+		}
+
+		quote, err := s.quote(msg.miniTicker)
+		if err != nil {
+			log.Printf("Error converting Binance tick for %s: %v", msg.Symbol, err)
+			continue
+		}
+		select {
+		case ticks <- quote:
+		case <-done:
+			return
+		}
+	}
+}
+
+// quote преобразует событие miniTicker в котировку
+func (s *Streamer) quote(t miniTicker) (types.Quote, error) {
+	coin, ok := s.coins[t.Symbol]
+	if !ok {
+		return types.Quote{}, fmt.Errorf("unknown symbol")
+	}
+	price, err := strconv.ParseFloat(t.Close, 64)
+	if err != nil {
+		return types.Quote{}, err
+	}
+	quote := types.Quote{
+		Coin:      coin,
+		Quote:     types.DefaultQuote,
+		Price:     price,
+		Timestamp: t.EventTime / 1000,
+		Source:    Name,
+	}
+	if open, err := strconv.ParseFloat(t.Open, 64); err == nil && open != 0 {
+		change := (price - open) / open * 100
+		quote.Change24h = &change
+	}
+	if volume, err := strconv.ParseFloat(t.QuoteVolume, 64); err == nil {
+		quote.Volume24h = &volume
+	}
+	return quote, nil
+}
+
+// streamsFor возвращает имена потоков для монет, у которых есть символ Binance
+func (s *Streamer) streamsFor(coins []string) map[string]struct{} {
+	streams := make(map[string]struct{}, len(coins))
+	for _, coin := range coins {
+		symbol, ok := s.symbols[coin]
+		if !ok {
+			log.Printf("No Binance symbol configured for %s, skipping stream", coin)
+			continue
+		}
+		streams[strings.ToLower(symbol)+"@miniTicker"] = struct{}{}
+	}
+	return streams
+}
+
+func (s *Streamer) dial(ctx context.Context) (*websocket.Conn, error) {
+	config, err := websocket.NewConfig(s.url, "http://localhost/")
+	if err != nil {
+		return nil, err
+	}
+	return config.DialContext(ctx)
+}
+
+// send отправляет команду подписки/отписки
+func (s *Streamer) send(conn *websocket.Conn, method string, streams []string) error {
+	if len(streams) == 0 {
+		return nil
+	}
+	return s.sendCommand(conn, method, streams)
+}
+
+// sendCommand отправляет команду WebSocket API
+func (s *Streamer) sendCommand(conn *websocket.Conn, method string, params []string) error {
+	s.nextID++
+	return websocket.JSON.Send(conn, streamCommand{
+		Method: method,
+		Params: params,
+		ID:     s.nextID,
+	})
+}
+
+func keys(set map[string]struct{}) []string {
+	result := make([]string, 0, len(set))
+	for key := range set {
+		result = append(result, key)
+	}
+	return result
+}
@@ -0,0 +1,205 @@
+package binance
+
+import (
+	"CryptoPriceCollection/internal/types"
+	"context"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// streamStandIn локальная замена WebSocket API Binance: записывает команды и отдает соединение обработчику теста
+type streamStandIn struct {
+	server   *httptest.Server
+	conns    atomic.Int32
+	mu       sync.Mutex
+	commands []streamCommand
+	closed   chan struct{}
+}
+
+func newStreamStandIn(t *testing.T, handle func(n int32, conn *websocket.Conn, commands <-chan streamCommand)) *streamStandIn {
+	t.Helper()
+	s := &streamStandIn{closed: make(chan struct{})}
+	s.server = httptest.NewServer(websocket.Handler(func(conn *websocket.Conn) {
+		n := s.conns.Add(1)
+		commands := make(chan streamCommand, 16)
+		go func() {
+			defer close(commands)
+			for {
+				var cmd streamCommand
+				if err := websocket.JSON.Receive(conn, &cmd); err != nil {
+					return
+				}
+				s.mu.Lock()
+				s.commands = append(s.commands, cmd)
+				s.mu.Unlock()
+				commands <- cmd
+			}
+		}()
+		handle(n, conn, commands)
+	}))
+	t.Cleanup(func() {
+		close(s.closed)
+		s.server.Close()
+	})
+	return s
+}
+
+func (s *streamStandIn) url() string {
+	return "ws" + strings.TrimPrefix(s.server.URL, "http")
+}
+
+func (s *streamStandIn) methods() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var methods []string
+	for _, cmd := range s.commands {
+		methods = append(methods, cmd.Method+" "+strings.Join(cmd.Params, ","))
+	}
+	return methods
+}
+
+// sendTick отправляет событие miniTicker; ошибка отправки проявится в тесте отсутствием котировки
+func sendTick(conn *websocket.Conn, symbol, price string) {
+	msg := `{"e":"24hrMiniTicker","E":1754645360123,"s":"` + symbol + `","c":"` + price + `","o":"100","q":"5000"}`
+	_ = websocket.Message.Send(conn, msg)
+}
+
+func runStreamer(t *testing.T, s *Streamer) (chan<- []string, <-chan types.Quote) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	watchlist := make(chan []string, 1)
+	out := make(chan types.Quote, 16)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = s.StreamPrices(ctx, watchlist, out)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return watchlist, out
+}
+
+func receiveQuote(t *testing.T, out <-chan types.Quote, timeout time.Duration) types.Quote {
+	t.Helper()
+	select {
+	case quote := <-out:
+		return quote
+	case <-time.After(timeout):
+		t.Fatal("no quote received")
+		return types.Quote{}
+	}
+}
+
+func TestStreamPricesDeliversTicks(t *testing.T) {
+	standIn := newStreamStandIn(t, func(_ int32, conn *websocket.Conn, commands <-chan streamCommand) {
+		for cmd := range commands {
+			if cmd.Method == "SUBSCRIBE" {
+				sendTick(conn, "BTCUSDT", "110")
+			}
+		}
+	})
+
+	streamer := NewStreamer(standIn.url(), map[string]string{"bitcoin": "BTCUSDT"})
+	watchlist, out := runStreamer(t, streamer)
+	watchlist <- []string{"bitcoin"}
+
+	quote := receiveQuote(t, out, 5*time.Second)
+	if quote.Coin != "bitcoin" || quote.Price != 110 || quote.Source != Name || quote.Timestamp != 1754645360 {
+		t.Fatalf("unexpected quote %+v", quote)
+	}
+	if quote.Change24h == nil || *quote.Change24h != 10 {
+		t.Fatalf("change_24h = %v, want 10", quote.Change24h)
+	}
+	if quote.Volume24h == nil || *quote.Volume24h != 5000 {
+		t.Fatalf("volume_24h = %v, want 5000", quote.Volume24h)
+	}
+}
+
+func TestStreamPricesUpdatesSubscriptions(t *testing.T) {
+	standIn := newStreamStandIn(t, func(_ int32, conn *websocket.Conn, commands <-chan streamCommand) {
+		for cmd := range commands {
+			if cmd.Method == "SUBSCRIBE" && cmd.Params[0] == "ethusdt@miniTicker" {
+				sendTick(conn, "ETHUSDT", "3000")
+			}
+		}
+	})
+
+	streamer := NewStreamer(standIn.url(), map[string]string{"bitcoin": "BTCUSDT", "ethereum": "ETHUSDT"})
+	watchlist, out := runStreamer(t, streamer)
+	watchlist <- []string{"bitcoin"}
+	time.Sleep(100 * time.Millisecond)
+	watchlist <- []string{"ethereum"}
+
+	if quote := receiveQuote(t, out, 5*time.Second); quote.Coin != "ethereum" {
+		t.Fatalf("unexpected quote %+v", quote)
+	}
+	want := []string{"SUBSCRIBE btcusdt@miniTicker", "UNSUBSCRIBE btcusdt@miniTicker", "SUBSCRIBE ethusdt@miniTicker"}
+	got := standIn.methods()
+	if strings.Join(got, ";") != strings.Join(want, ";") {
+		t.Fatalf("commands = %v, want %v", got, want)
+	}
+}
+
+func TestStreamPricesReconnectsSilentConnection(t *testing.T) {
+	var standIn *streamStandIn
+	standIn = newStreamStandIn(t, func(n int32, conn *websocket.Conn, commands <-chan streamCommand) {
+		if n == 1 {
+			// Полуоткрытое соединение: команды принимаются, но ответа и тиков нет, сокет не закрывается
+			for {
+				select {
+				case <-commands:
+				case <-standIn.closed:
+					return
+				}
+			}
+		}
+		for cmd := range commands {
+			if cmd.Method == "SUBSCRIBE" {
+				sendTick(conn, "BTCUSDT", "120")
+			}
+		}
+	})
+
+	streamer := NewStreamer(standIn.url(), map[string]string{"bitcoin": "BTCUSDT"})
+	streamer.readTimeout = 200 * time.Millisecond
+	watchlist, out := runStreamer(t, streamer)
+	watchlist <- []string{"bitcoin"}
+
+	if quote := receiveQuote(t, out, 5*time.Second); quote.Price != 120 {
+		t.Fatalf("unexpected quote %+v", quote)
+	}
+	if n := standIn.conns.Load(); n < 2 {
+		t.Fatalf("connections = %d, want reconnect", n)
+	}
+}
+
+func TestStreamPricesPingKeepsQuietConnection(t *testing.T) {
+	standIn := newStreamStandIn(t, func(_ int32, conn *websocket.Conn, commands <-chan streamCommand) {
+		for cmd := range commands {
+			if cmd.Method == "LIST_SUBSCRIPTIONS" {
+				if err := websocket.Message.Send(conn, `{"result":["btcusdt@miniTicker"],"id":1}`); err != nil {
+					return
+				}
+			}
+		}
+	})
+
+	streamer := NewStreamer(standIn.url(), map[string]string{"bitcoin": "BTCUSDT"})
+	streamer.pingInterval = 50 * time.Millisecond
+	streamer.readTimeout = 300 * time.Millisecond
+	watchlist, _ := runStreamer(t, streamer)
+	watchlist <- []string{"bitcoin"}
+
+	time.Sleep(time.Second)
+	if n := standIn.conns.Load(); n != 1 {
+		t.Fatalf("connections = %d, quiet connection answering pings must not be dropped", n)
+	}
+}
@@ -0,0 +1,182 @@
+package breaker
+
+import (
+	"CryptoPriceCollection/internal/types"
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// Состояния автоматического выключателя
+const (
+	StateClosed   = "closed"    // запросы идут к поставщику
+	StateOpen     = "open"      // запросы отклоняются без обращения к поставщику
+	StateHalfOpen = "half-open" // пропускается пробный запрос
+)
+
+// ErrOpen выключатель разомкнут, поставщик временно не опрашивается
+var ErrOpen = errors.New("circuit breaker is open")
+
+// priceProvider поставщик цен, которого защищает выключатель
+type priceProvider interface {
+	Name() string
+	FetchPrices(ctx context.Context, coins []string) ([]types.Quote, error)
+}
+
+// Settings пороги срабатывания выключателя
+type Settings struct {
+	FailureThreshold  int           // подряд неудачных запросов до размыкания
+	OpenTimeout       time.Duration // время в разомкнутом состоянии до пробного запроса
+	HalfOpenSuccesses int           // успешных пробных запросов до замыкания
+}
+
+type Breaker struct {
+	provider priceProvider
+	settings Settings
+
+	mu        sync.Mutex
+	state     string
+	failures  int
+	successes int
+	trial     bool // пробный запрос в полуоткрытом состоянии уже выполняется
+	openedAt  time.Time
+	lastError string
+	now       func() time.Time
+}
+
+func New(provider priceProvider, settings Settings) *Breaker {
+	if settings.FailureThreshold < 1 {
+		settings.FailureThreshold = 3
+	}
+	if settings.OpenTimeout <= 0 {
+		settings.OpenTimeout = time.Minute
+	}
+	if settings.HalfOpenSuccesses < 1 {
+		settings.HalfOpenSuccesses = 1
+	}
+	return &Breaker{
+		provider: provider,
+		settings: settings,
+		state:    StateClosed,
+		now:      time.Now,
+	}
+}
+
+// SetClock задает источник текущего времени для отсчета OpenTimeout
+func (b *Breaker) SetClock(now func() time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.now = now
+}
+
+// Name возвращает название защищаемого поставщика
+func (b *Breaker) Name() string {
+	return b.provider.Name()
+}
+
+// FetchPrices обращается к поставщику, если выключатель это позволяет
+func (b *Breaker) FetchPrices(ctx context.Context, coins []string) ([]types.Quote, error) {
+	if !b.allow() {
+		return nil, ErrOpen
+	}
+
+	quotes, err := b.provider.FetchPrices(ctx, coins)
+	// Отмена контекста при остановке сервиса не говорит о неисправности поставщика
+	if err != nil && ctx.Err() != nil {
+		b.mu.Lock()
+		b.trial = false
+		b.mu.Unlock()
+		return nil, err
+	}
+	b.record(err)
+	return quotes, err
+}
+
+// Status возвращает текущее состояние выключателя
+func (b *Breaker) Status() types.ProviderStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := types.ProviderStatus{
+		Name:      b.provider.Name(),
+		State:     b.state,
+		Failures:  b.failures,
+		LastError: b.lastError,
+	}
+	if b.state != StateClosed {
+		openedAt := b.openedAt.Unix()
+		status.OpenedAt = &openedAt
+	}
+	return status
+}
+
+// allow решает, можно ли выполнить запрос, и переводит выключатель в полуоткрытое состояние по таймауту
+func (b *Breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		if b.now().Sub(b.openedAt) < b.settings.OpenTimeout {
+			return false
+		}
+		b.transition(StateHalfOpen)
+		b.successes = 0
+		fallthrough
+	case StateHalfOpen:
+		if b.trial {
+			return false
+		}
+		b.trial = true
+		return true
+	default:
+		return true
+	}
+}
+
+// record учитывает результат запроса
+func (b *Breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err != nil {
+		b.lastError = err.Error()
+		b.failures++
+		switch b.state {
+		case StateHalfOpen:
+			b.trial = false
+			b.open()
+		case StateClosed:
+			if b.failures >= b.settings.FailureThreshold {
+				b.open()
+			}
+		}
+		return
+	}
+
+	switch b.state {
+	case StateHalfOpen:
+		b.trial = false
+		b.successes++
+		if b.successes >= b.settings.HalfOpenSuccesses {
+			b.failures = 0
+			b.transition(StateClosed)
+		}
+	default:
+		b.failures = 0
+	}
+}
+
+func (b *Breaker) open() {
+	b.openedAt = b.now()
+	b.transition(StateOpen)
+}
+
+func (b *Breaker) transition(state string) {
+	if b.state != state {
+		log.Printf("Circuit breaker for %s: %s -> %s", b.provider.Name(), b.state, state)
+	}
+	b.state = state
+}
@@ -0,0 +1,148 @@
+package breaker
+
+import (
+	"CryptoPriceCollection/internal/types"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errProvider = errors.New("provider is down")
+
+// stubProvider отвечает ошибкой err и считает обращения
+type stubProvider struct {
+	err   error
+	calls int
+}
+
+func (p *stubProvider) Name() string {
+	return "stub"
+}
+
+func (p *stubProvider) FetchPrices(ctx context.Context, coins []string) ([]types.Quote, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return []types.Quote{{Coin: "bitcoin", Price: 1}}, nil
+}
+
+// step один запрос через выключатель: сдвиг часов перед запросом, ответ поставщика и ожидаемый результат
+type step struct {
+	advance   time.Duration
+	fail      bool
+	wantErr   error
+	wantState string
+	wantCall  bool
+}
+
+func TestBreakerStateMachine(t *testing.T) {
+	settings := Settings{FailureThreshold: 2, OpenTimeout: time.Minute, HalfOpenSuccesses: 2}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "stays closed below threshold",
+			steps: []step{
+				{fail: true, wantErr: errProvider, wantState: StateClosed, wantCall: true},
+				{wantState: StateClosed, wantCall: true},
+				{fail: true, wantErr: errProvider, wantState: StateClosed, wantCall: true},
+			},
+		},
+		{
+			name: "opens at threshold and rejects",
+			steps: []step{
+				{fail: true, wantErr: errProvider, wantState: StateClosed, wantCall: true},
+				{fail: true, wantErr: errProvider, wantState: StateOpen, wantCall: true},
+				{wantErr: ErrOpen, wantState: StateOpen},
+				{advance: 59 * time.Second, wantErr: ErrOpen, wantState: StateOpen},
+			},
+		},
+		{
+			name: "half-open closes after successes",
+			steps: []step{
+				{fail: true, wantErr: errProvider, wantState: StateClosed, wantCall: true},
+				{fail: true, wantErr: errProvider, wantState: StateOpen, wantCall: true},
+				{advance: time.Minute, wantState: StateHalfOpen, wantCall: true},
+				{wantState: StateClosed, wantCall: true},
+				{fail: true, wantErr: errProvider, wantState: StateClosed, wantCall: true},
+			},
+		},
+		{
+			name: "half-open reopens on failure",
+			steps: []step{
+				{fail: true, wantErr: errProvider, wantState: StateClosed, wantCall: true},
+				{fail: true, wantErr: errProvider, wantState: StateOpen, wantCall: true},
+				{advance: time.Minute, wantState: StateHalfOpen, wantCall: true},
+				{fail: true, wantErr: errProvider, wantState: StateOpen, wantCall: true},
+				{advance: 30 * time.Second, wantErr: ErrOpen, wantState: StateOpen},
+				{advance: 30 * time.Second, wantState: StateHalfOpen, wantCall: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &stubProvider{}
+			b := New(provider, settings)
+			now := time.Date(2024, 5, 10, 10, 0, 0, 0, time.UTC)
+			b.SetClock(func() time.Time { return now })
+
+			for i, st := range tt.steps {
+				now = now.Add(st.advance)
+				provider.err = nil
+				if st.fail {
+					provider.err = errProvider
+				}
+				calls := provider.calls
+
+				_, err := b.FetchPrices(context.Background(), []string{"bitcoin"})
+				if !errors.Is(err, st.wantErr) || (st.wantErr == nil && err != nil) {
+					t.Fatalf("step %d: error = %v, want %v", i, err, st.wantErr)
+				}
+				if called := provider.calls > calls; called != st.wantCall {
+					t.Fatalf("step %d: provider called = %v, want %v", i, called, st.wantCall)
+				}
+				status := b.Status()
+				if status.State != st.wantState {
+					t.Fatalf("step %d: state = %s, want %s", i, status.State, st.wantState)
+				}
+				if st.wantState == StateClosed && status.OpenedAt != nil {
+					t.Fatalf("step %d: closed breaker reports opened_at", i)
+				}
+			}
+		})
+	}
+}
+
+func TestBreakerHalfOpenAllowsSingleTrial(t *testing.T) {
+	b := New(&stubProvider{}, Settings{FailureThreshold: 1, OpenTimeout: time.Minute})
+	now := time.Date(2024, 5, 10, 10, 0, 0, 0, time.UTC)
+	b.SetClock(func() time.Time { return now })
+	b.record(errProvider)
+
+	now = now.Add(time.Minute)
+	if !b.allow() {
+		t.Fatal("first trial request was rejected")
+	}
+	if b.allow() {
+		t.Fatal("second request allowed while the trial is in flight")
+	}
+	b.record(nil)
+	if status := b.Status(); status.State != StateClosed {
+		t.Fatalf("state = %s, want %s", status.State, StateClosed)
+	}
+}
+
+func TestBreakerIgnoresCanceledContext(t *testing.T) {
+	b := New(&stubProvider{err: context.Canceled}, Settings{FailureThreshold: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := b.FetchPrices(ctx, []string{"bitcoin"}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("error = %v, want %v", err, context.Canceled)
+	}
+	if status := b.Status(); status.State != StateClosed || status.Failures != 0 {
+		t.Fatalf("status = %+v, want closed without failures", status)
+	}
+}
@@ -0,0 +1,117 @@
+package cbr
+
+import (
+	"CryptoPriceCollection/internal/types"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Name название поставщика в конфигурации
+const Name = "cbr"
+
+// DefaultURL адрес ежедневных курсов ЦБ РФ по умолчанию
+const DefaultURL = "https://www.cbr.ru/scripts/XML_daily.asp"
+
+type Provider struct {
+	client *http.Client
+	url    string
+}
+
+// valCurs ответ XML_daily.asp: стоимость Nominal единиц валюты в рублях
+type valCurs struct {
+	Date    string `xml:"Date,attr"`
+	Valutes []struct {
+		CharCode string `xml:"CharCode"`
+		Nominal  int    `xml:"Nominal"`
+		Value    string `xml:"Value"` // с десятичной запятой
+	} `xml:"Valute"`
+}
+
+func New(url string, client *http.Client) *Provider {
+	if url == "" {
+		url = DefaultURL
+	}
+	return &Provider{
+		client: client,
+		url:    url,
+	}
+}
+
+// Name возвращает название поставщика
+func (p *Provider) Name() string {
+	return Name
+}
+
+// FetchRates получает курсы ЦБ РФ и пересчитывает их из RUB в USD
+func (p *Provider) FetchRates(ctx context.Context) ([]types.FXRate, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request to CBR: %w", err)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request error to CBR: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected CBR status %d", resp.StatusCode)
+	}
+	return Parse(resp.Body)
+}
+
+// Parse разбирает XML_daily.asp (в кодировке windows-1251) в курсы за 1 USD
+func Parse(r io.Reader) ([]types.FXRate, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		if !strings.EqualFold(charset, "windows-1251") {
+			return nil, fmt.Errorf("unsupported charset %q", charset)
+		}
+		return charmap.Windows1251.NewDecoder().Reader(input), nil
+	}
+	var doc valCurs
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decoding CBR XML: %w", err)
+	}
+	date, err := time.Parse("02.01.2006", doc.Date)
+	if err != nil {
+		return nil, fmt.Errorf("parsing CBR date %q: %w", doc.Date, err)
+	}
+
+	// Рублей за единицу валюты
+	rubPer := make(map[string]float64, len(doc.Valutes)+1)
+	for _, valute := range doc.Valutes {
+		value, err := strconv.ParseFloat(strings.Replace(valute.Value, ",", ".", 1), 64)
+		if err != nil || valute.Nominal <= 0 {
+			continue
+		}
+		rubPer[strings.ToLower(valute.CharCode)] = value / float64(valute.Nominal)
+	}
+	rubPer["rub"] = 1
+	usd, ok := rubPer[types.DefaultQuote]
+	if !ok || usd == 0 {
+		return nil, fmt.Errorf("no USD rate in CBR XML")
+	}
+
+	rates := make([]types.FXRate, 0, len(rubPer))
+	for currency, value := range rubPer {
+		if currency == types.DefaultQuote || value == 0 {
+			continue
+		}
+		rates = append(rates, types.FXRate{
+			Base:      types.DefaultQuote,
+			Currency:  currency,
+			Rate:      usd / value,
+			Timestamp: date.Unix(),
+			Source:    Name,
+		})
+	}
+	return rates, nil
+}
@@ -0,0 +1,68 @@
+package cbr
+
+import (
+	"CryptoPriceCollection/internal/types"
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	f, err := os.Open("testdata/XML_daily.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	rates, err := Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// USD 92,5 руб.; EUR 100 руб.; 10 CNY 125 руб.; JPY с нулевым Nominal пропускается
+	want := map[string]float64{
+		"rub": 92.5,
+		"eur": 0.925,
+		"cny": 92.5 / 12.5,
+	}
+	date := time.Date(2024, 5, 11, 0, 0, 0, 0, time.UTC)
+	if len(rates) != len(want) {
+		t.Fatalf("got %d rates, want %d: %v", len(rates), len(want), rates)
+	}
+	for _, rate := range rates {
+		expected, ok := want[rate.Currency]
+		if !ok {
+			t.Fatalf("unexpected currency %q", rate.Currency)
+		}
+		if math.Abs(rate.Rate-expected) > 1e-9 {
+			t.Errorf("%s rate = %v, want %v", rate.Currency, rate.Rate, expected)
+		}
+		if rate.Base != types.DefaultQuote || rate.Source != Name {
+			t.Errorf("%s base/source = %s/%s", rate.Currency, rate.Base, rate.Source)
+		}
+		if rate.Timestamp != date.Unix() {
+			t.Errorf("%s timestamp = %d, want %d", rate.Currency, rate.Timestamp, date.Unix())
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "not XML", body: "rates"},
+		{name: "unsupported charset", body: `<?xml version="1.0" encoding="koi8-r"?><ValCurs Date="11.05.2024"></ValCurs>`},
+		{name: "bad date", body: `<ValCurs Date="2024-05-11"><Valute><CharCode>USD</CharCode><Nominal>1</Nominal><Value>92,5</Value></Valute></ValCurs>`},
+		{name: "missing USD rate", body: `<ValCurs Date="11.05.2024"><Valute><CharCode>EUR</CharCode><Nominal>1</Nominal><Value>100,0</Value></Valute></ValCurs>`},
+		{name: "malformed USD value", body: `<ValCurs Date="11.05.2024"><Valute><CharCode>USD</CharCode><Nominal>1</Nominal><Value>n/a</Value></Valute></ValCurs>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rates, err := Parse(strings.NewReader(tt.body)); err == nil {
+				t.Fatalf("expected error, got %v", rates)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package coinbase
+
+import (
+	"CryptoPriceCollection/internal/types"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Name название поставщика в конфигурации
+const Name = "coinbase"
+
+// DefaultBaseURL адрес REST API Coinbase Exchange по умолчанию
+const DefaultBaseURL = "https://api.exchange.coinbase.com"
+
+// errRateLimited превышен лимит запросов Coinbase
+var errRateLimited = errors.New("coinbase rate limit exceeded")
+
+type Provider struct {
+	client   *http.Client
+	baseURL  string
+	products map[string]string // id монеты -> продукт Coinbase (BTC-USD)
+}
+
+// usdQuotes валюты котировки продуктов, приравниваемые к USD
+var usdQuotes = []string{"USD", "USDT", "USDC"}
+
+// ticker ответ /products/{id}/ticker
+type ticker struct {
+	Price  string    `json:"price"`
+	Volume string    `json:"volume"`
+	Time   time.Time `json:"time"`
+}
+
+// apiError тело ошибки Coinbase
+type apiError struct {
+	Message string `json:"message"`
+}
+
+func New(baseURL string, products map[string]string, client *http.Client) *Provider {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Provider{
+		client:   client,
+		baseURL:  baseURL,
+		products: products,
+	}
+}
+
+// CheckProducts проверяет, что все продукты котируются в USD: цены Coinbase сохраняются с валютой котировки usd
+func CheckProducts(products map[string]string) error {
+	coins := make([]string, 0, len(products))
+	for coin := range products {
+		coins = append(coins, coin)
+	}
+	sort.Strings(coins)
+	for _, coin := range coins {
+		base, quote, ok := strings.Cut(strings.ToUpper(products[coin]), "-")
+		if !ok || base == "" || !slices.Contains(usdQuotes, quote) {
+			return fmt.Errorf("product %s for %s is not quoted in %s", products[coin], coin, types.DefaultQuote)
+		}
+	}
+	return nil
+}
+
+// Name возвращает название поставщика
+func (p *Provider) Name() string {
+	return Name
+}
+
+// FetchPrices получает цены через /products/{id}/ticker, по одному запросу на монету
+func (p *Provider) FetchPrices(ctx context.Context, coins []string) ([]types.Quote, error) {
+	quotes := make([]types.Quote, 0, len(coins))
+	var lastErr error
+	for _, coin := range coins {
+		product, ok := p.products[coin]
+		if !ok {
+			log.Printf("No Coinbase product configured for %s", coin)
+			continue
+		}
+
+		quote, err := p.fetchTicker(ctx, coin, product)
+		if err != nil {
+			log.Printf("Error fetching Coinbase ticker for %s: %v", product, err)
+			lastErr = err
+			// После 429 остальные запросы тика тоже будут отклонены
+			if errors.Is(err, errRateLimited) {
+				break
+			}
+			continue
+		}
+		quotes = append(quotes, quote)
+	}
+
+	// Если запросов не было, потому что монеты не сопоставлены продуктам, это не сбой биржи
+	if len(quotes) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return quotes, nil
+}
+
+// fetchTicker получает тикер одного продукта
+func (p *Provider) fetchTicker(ctx context.Context, coin, product string) (types.Quote, error) {
+	endpoint := fmt.Sprintf("%s/products/%s/ticker", p.baseURL, url.PathEscape(product))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return types.Quote{}, fmt.Errorf("creating request to Coinbase: %w", err)
+	}
+	// Coinbase отклоняет запросы без User-Agent
+	req.Header.Set("User-Agent", "CryptoPriceCollection")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return types.Quote{}, fmt.Errorf("HTTP request error to Coinbase: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return types.Quote{}, fmt.Errorf("error reading the Coinbase response: %w", err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return types.Quote{}, fmt.Errorf("%w (retry after %q)", errRateLimited, resp.Header.Get("Retry-After"))
+	case resp.StatusCode != http.StatusOK:
+		var apiErr apiError
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
+			return types.Quote{}, fmt.Errorf("coinbase error: %s (status %d)", apiErr.Message, resp.StatusCode)
+		}
+		return types.Quote{}, fmt.Errorf("unexpected Coinbase status %d", resp.StatusCode)
+	}
+
+	var t ticker
+	if err := json.Unmarshal(body, &t); err != nil {
+		return types.Quote{}, fmt.Errorf("decoding error JSON: %w", err)
+	}
+	price, err := strconv.ParseFloat(t.Price, 64)
+	if err != nil {
+		return types.Quote{}, fmt.Errorf("converting price: %w", err)
+	}
+
+	quote := types.Quote{
+		Coin:      coin,
+		Quote:     types.DefaultQuote,
+		Price:     price,
+		Timestamp: time.Now().Unix(),
+		Source:    Name,
+	}
+	if !t.Time.IsZero() {
+		quote.Timestamp = t.Time.Unix()
+	}
+	// Coinbase отдает объем в базовой валюте, приводим к валюте котировки
+	if volume, err := strconv.ParseFloat(t.Volume, 64); err == nil {
+		quoteVolume := volume * price
+		quote.Volume24h = &quoteVolume
+	}
+	return quote, nil
+}
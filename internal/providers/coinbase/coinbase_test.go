@@ -0,0 +1,166 @@
+package coinbase
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newStandIn локальная замена /products/{id}/ticker с ответами по продукту; неизвестный продукт - 404
+func newStandIn(t *testing.T, responses map[string]string, statuses map[string]int) (*Provider, func() []string) {
+	t.Helper()
+	var (
+		mu        sync.Mutex
+		requested []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		product, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/products/"), "/ticker")
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		mu.Lock()
+		requested = append(requested, product)
+		mu.Unlock()
+		if r.Header.Get("User-Agent") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if status, ok := statuses[product]; ok {
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(`{"message":"unavailable"}`))
+			return
+		}
+		body, ok := responses[product]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"NotFound"}`))
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	provider := New(server.URL, map[string]string{"bitcoin": "BTC-USD", "ethereum": "ETH-USD", "solana": "SOL-USD"}, server.Client())
+	return provider, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), requested...)
+	}
+}
+
+var tickers = map[string]string{
+	"BTC-USD": `{"price":"64000.5","volume":"10","time":"2023-11-14T22:13:20Z"}`,
+	"ETH-USD": `{"price":"3100","volume":"100","time":"2023-11-14T22:13:20Z"}`,
+	"SOL-USD": `{"price":"55","volume":"1000","time":"2023-11-14T22:13:20Z"}`,
+}
+
+func TestFetchPricesFansOutPerProduct(t *testing.T) {
+	provider, requested := newStandIn(t, tickers, nil)
+
+	quotes, err := provider.FetchPrices(context.Background(), []string{"bitcoin", "ethereum", "dogecoin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := requested(); strings.Join(got, ",") != "BTC-USD,ETH-USD" {
+		t.Fatalf("requested products %v, want one request per mapped coin", got)
+	}
+
+	sort.Slice(quotes, func(i, j int) bool { return quotes[i].Coin < quotes[j].Coin })
+	if len(quotes) != 2 {
+		t.Fatalf("got %d quotes, want 2", len(quotes))
+	}
+	tests := []struct {
+		coin   string
+		price  float64
+		volume float64
+	}{
+		{coin: "bitcoin", price: 64000.5, volume: 640005},
+		{coin: "ethereum", price: 3100, volume: 310000},
+	}
+	for i, tt := range tests {
+		quote := quotes[i]
+		if quote.Coin != tt.coin || quote.Price != tt.price || quote.Quote != "usd" || quote.Source != Name {
+			t.Errorf("quote %d = %+v, want %s at %v usd", i, quote, tt.coin, tt.price)
+		}
+		if quote.Timestamp != 1700000000 {
+			t.Errorf("%s timestamp = %d, want the ticker time", tt.coin, quote.Timestamp)
+		}
+		if quote.Volume24h == nil || *quote.Volume24h != tt.volume {
+			t.Errorf("%s volume = %v, want %v", tt.coin, quote.Volume24h, tt.volume)
+		}
+	}
+}
+
+func TestFetchPricesFailures(t *testing.T) {
+	coins := []string{"bitcoin", "ethereum", "solana"}
+	tests := []struct {
+		name          string
+		statuses      map[string]int
+		wantCoins     string
+		wantRequested string
+		wantErr       bool
+	}{
+		{
+			name:          "one product fails",
+			statuses:      map[string]int{"ETH-USD": http.StatusInternalServerError},
+			wantCoins:     "bitcoin,solana",
+			wantRequested: "BTC-USD,ETH-USD,SOL-USD",
+		},
+		{
+			// После 429 остальные продукты не запрашиваются
+			name:          "rate limit stops the fan-out",
+			statuses:      map[string]int{"ETH-USD": http.StatusTooManyRequests},
+			wantCoins:     "bitcoin",
+			wantRequested: "BTC-USD,ETH-USD",
+		},
+		{
+			name:          "all products fail",
+			statuses:      map[string]int{"BTC-USD": 500, "ETH-USD": 500, "SOL-USD": 500},
+			wantRequested: "BTC-USD,ETH-USD,SOL-USD",
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, requested := newStandIn(t, tickers, tt.statuses)
+			quotes, err := provider.FetchPrices(context.Background(), coins)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FetchPrices() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, quote := range quotes {
+				got = append(got, quote.Coin)
+			}
+			if strings.Join(got, ",") != tt.wantCoins {
+				t.Errorf("quotes for %v, want %s", got, tt.wantCoins)
+			}
+			if got := strings.Join(requested(), ","); got != tt.wantRequested {
+				t.Errorf("requested %s, want %s", got, tt.wantRequested)
+			}
+		})
+	}
+}
+
+func TestCheckProducts(t *testing.T) {
+	tests := []struct {
+		products map[string]string
+		wantErr  bool
+	}{
+		{products: map[string]string{"bitcoin": "BTC-USD", "ethereum": "eth-usdc"}},
+		{products: map[string]string{"tether": "USDT-USD", "ethereum": "ETH-USDT"}},
+		{products: map[string]string{"bitcoin": "BTC-EUR"}, wantErr: true},
+		{products: map[string]string{"ethereum": "ETH-BTC"}, wantErr: true},
+		{products: map[string]string{"bitcoin": "BTCUSD"}, wantErr: true},
+		{products: map[string]string{"bitcoin": "-USD"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := CheckProducts(tt.products); (err != nil) != tt.wantErr {
+			t.Errorf("CheckProducts(%v) error = %v, wantErr %v", tt.products, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,395 @@
+package coingecko
+
+import (
+	"CryptoPriceCollection/internal/types"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Name название поставщика в конфигурации
+const Name = "coingecko"
+
+// Адреса API CoinGecko
+const (
+	PublicBaseURL = "https://api.coingecko.com/api/v3"
+	ProBaseURL    = "https://pro-api.coingecko.com/api/v3"
+)
+
+// Тарифы CoinGecko, определяющие заголовок ключа
+const (
+	PlanDemo = "demo"
+	PlanPro  = "pro"
+)
+
+// Значения по умолчанию для разбиения списка монет
+const (
+	defaultChunkSize    = 100
+	defaultChunkWorkers = 2
+)
+
+// Settings настройки клиента CoinGecko
+type Settings struct {
+	BaseURL      string
+	Plan         string // demo, pro или пусто
+	APIKey       string
+	ChunkSize    int      // максимум монет в одном запросе
+	ChunkWorkers int      // параллельных запросов по частям списка
+	VsCurrencies []string // валюты котировки
+}
+
+type Provider struct {
+	client       *http.Client
+	baseURL      string
+	keyHeader    string
+	apiKey       string
+	chunkSize    int
+	chunkWorkers int
+	vsCurrencies []string
+}
+
+// New создает клиент CoinGecko. Для тарифа pro по умолчанию используется Pro API.
+func New(settings Settings, client *http.Client) *Provider {
+	baseURL := settings.BaseURL
+	var keyHeader string
+	switch settings.Plan {
+	case PlanPro:
+		keyHeader = "x-cg-pro-api-key"
+		if baseURL == "" || baseURL == PublicBaseURL {
+			baseURL = ProBaseURL
+		}
+	case PlanDemo:
+		keyHeader = "x-cg-demo-api-key"
+	}
+	if baseURL == "" {
+		baseURL = PublicBaseURL
+	}
+	chunkSize := settings.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultChunkSize
+	}
+	chunkWorkers := settings.ChunkWorkers
+	if chunkWorkers <= 0 {
+		chunkWorkers = defaultChunkWorkers
+	}
+	vsCurrencies := settings.VsCurrencies
+	if len(vsCurrencies) == 0 {
+		vsCurrencies = []string{types.DefaultQuote}
+	}
+	return &Provider{
+		vsCurrencies: vsCurrencies,
+		client:       client,
+		baseURL:      baseURL,
+		keyHeader:    keyHeader,
+		apiKey:       settings.APIKey,
+		chunkSize:    chunkSize,
+		chunkWorkers: chunkWorkers,
+	}
+}
+
+// Name возвращает название поставщика
+func (p *Provider) Name() string {
+	return Name
+}
+
+// FetchPrices получает цены на несколько монет через /simple/price.
+// Список разбивается на части, которые запрашиваются параллельно; ошибка части не отменяет остальные.
+func (p *Provider) FetchPrices(ctx context.Context, coins []string) ([]types.Quote, error) {
+	var chunks [][]string
+	for start := 0; start < len(coins); start += p.chunkSize {
+		chunks = append(chunks, coins[start:min(start+p.chunkSize, len(coins))])
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		quotes  = make([]types.Quote, 0, len(coins)*len(p.vsCurrencies))
+		failed  int
+		lastErr error
+	)
+	jobs := make(chan int)
+	for w := 0; w < min(p.chunkWorkers, len(chunks)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				chunkQuotes, err := p.fetchChunk(ctx, chunks[i])
+				mu.Lock()
+				if err != nil {
+					log.Printf("Error fetching CoinGecko chunk %d/%d (%d coins, %s..%s): %v",
+						i+1, len(chunks), len(chunks[i]), chunks[i][0], chunks[i][len(chunks[i])-1], err)
+					failed++
+					lastErr = err
+				}
+				quotes = append(quotes, chunkQuotes...)
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range chunks {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	if failed > 0 && failed == len(chunks) {
+		return nil, lastErr
+	}
+	if failed > 0 {
+		log.Printf("CoinGecko returned partial data: %d of %d chunks failed", failed, len(chunks))
+	}
+	return quotes, nil
+}
+
+// fetchChunk получает цены на часть списка монет одним запросом
+func (p *Provider) fetchChunk(ctx context.Context, coins []string) ([]types.Quote, error) {
+	query := url.Values{}
+	query.Set("ids", strings.Join(coins, ","))
+	query.Set("vs_currencies", strings.Join(p.vsCurrencies, ","))
+	query.Set("include_market_cap", "true")
+	query.Set("include_24hr_vol", "true")
+	query.Set("include_24hr_change", "true")
+	query.Set("include_last_updated_at", "true")
+	body, err := p.get(ctx, "/simple/price", query)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Response CoinGecko API: %s", string(body))
+
+	var result map[string]map[string]interface{}
+	if err := json.NewDecoder(bytes.NewReader(body)).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decoding error JSON: %w", err)
+	}
+
+	now := time.Now().Unix()
+	quotes := make([]types.Quote, 0, len(coins)*len(p.vsCurrencies))
+	for _, coin := range coins {
+		priceData, ok := result[coin]
+		if !ok {
+			log.Printf("No data was found for %s", coin)
+			continue
+		}
+		timestamp := now
+		if updated, err := parseNumber(priceData["last_updated_at"]); err == nil && updated > 0 {
+			timestamp = int64(updated)
+		}
+		for _, vs := range p.vsCurrencies {
+			value, ok := priceData[vs]
+			if !ok {
+				log.Printf("The %s price was not found for %s", strings.ToUpper(vs), coin)
+				continue
+			}
+			price, err := parseNumber(value)
+			if err != nil {
+				log.Printf("Error converting the %s price for %s: %v", vs, coin, err)
+				continue
+			}
+			quotes = append(quotes, types.Quote{
+				Coin:      coin,
+				Quote:     vs,
+				Price:     price,
+				Timestamp: timestamp,
+				Source:    Name,
+				MarketCap: optionalNumber(priceData[vs+"_market_cap"]),
+				Volume24h: optionalNumber(priceData[vs+"_24h_vol"]),
+				Change24h: optionalNumber(priceData[vs+"_24h_change"]),
+			})
+		}
+	}
+	return quotes, nil
+}
+
+// marketChart ответ /coins/{id}/market_chart/range: пары [время в мс, значение]
+type marketChart struct {
+	Prices       [][2]float64 `json:"prices"`
+	MarketCaps   [][2]float64 `json:"market_caps"`
+	TotalVolumes [][2]float64 `json:"total_volumes"`
+}
+
+// FetchRange получает историю цен монеты за интервал [from, to] (unix-секунды) через /coins/{id}/market_chart/range.
+// Детализация выбирается API по длине интервала: до суток - 5 минут, до 90 дней - час, больше - сутки.
+func (p *Provider) FetchRange(ctx context.Context, coin, vs string, from, to int64) ([]types.Quote, error) {
+	query := url.Values{}
+	query.Set("vs_currency", vs)
+	query.Set("from", strconv.FormatInt(from, 10))
+	query.Set("to", strconv.FormatInt(to, 10))
+	body, err := p.get(ctx, "/coins/"+url.PathEscape(coin)+"/market_chart/range", query)
+	if err != nil {
+		return nil, err
+	}
+
+	var chart marketChart
+	if err := json.Unmarshal(body, &chart); err != nil {
+		return nil, fmt.Errorf("decoding error JSON: %w", err)
+	}
+
+	marketCaps := make(map[int64]float64, len(chart.MarketCaps))
+	for _, point := range chart.MarketCaps {
+		marketCaps[int64(point[0])/1000] = point[1]
+	}
+	volumes := make(map[int64]float64, len(chart.TotalVolumes))
+	for _, point := range chart.TotalVolumes {
+		volumes[int64(point[0])/1000] = point[1]
+	}
+
+	quotes := make([]types.Quote, 0, len(chart.Prices))
+	for _, point := range chart.Prices {
+		timestamp := int64(point[0]) / 1000
+		quote := types.Quote{
+			Coin:      coin,
+			Quote:     vs,
+			Price:     point[1],
+			Timestamp: timestamp,
+			Source:    Name,
+		}
+		if marketCap, ok := marketCaps[timestamp]; ok {
+			quote.MarketCap = &marketCap
+		}
+		if volume, ok := volumes[timestamp]; ok {
+			quote.Volume24h = &volume
+		}
+		quotes = append(quotes, quote)
+	}
+	return quotes, nil
+}
+
+// ohlcDays допустимые значения days для /coins/{id}/ohlc по детализации свечей, которую выбирает API
+var ohlcDays = map[string]struct {
+	duration time.Duration
+	days     []int
+}{
+	"30m": {30 * time.Minute, []int{1, 2}},
+	"4h":  {4 * time.Hour, []int{7, 14, 30}},
+	"4d":  {96 * time.Hour, []int{90, 180, 365}},
+}
+
+// FetchCandles получает свечи через /coins/{id}/ohlc. API не принимает интервал, а выбирает его по глубине истории,
+// поэтому поддерживаются только 30m, 4h и 4d; глубина подбирается так, чтобы покрыть from.
+func (p *Provider) FetchCandles(ctx context.Context, coin, quote, interval string, from, to int64) ([]types.Candle, error) {
+	granularity, ok := ohlcDays[interval]
+	if !ok {
+		return nil, fmt.Errorf("coingecko candles support only 30m, 4h and 4d intervals, got %q", interval)
+	}
+	needed := int(time.Since(time.Unix(from, 0)).Hours()/24) + 1
+	days := granularity.days[len(granularity.days)-1]
+	for _, d := range granularity.days {
+		if d >= needed {
+			days = d
+			break
+		}
+	}
+
+	query := url.Values{}
+	query.Set("vs_currency", quote)
+	query.Set("days", strconv.Itoa(days))
+	body, err := p.get(ctx, "/coins/"+url.PathEscape(coin)+"/ohlc", query)
+	if err != nil {
+		return nil, err
+	}
+
+	// Каждая свеча [время закрытия в мс, open, high, low, close]
+	var rows [][5]float64
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, fmt.Errorf("decoding error JSON: %w", err)
+	}
+
+	candles := make([]types.Candle, 0, len(rows))
+	for _, row := range rows {
+		openTime := int64(row[0])/1000 - int64(granularity.duration.Seconds())
+		if openTime < from || openTime > to {
+			continue
+		}
+		candles = append(candles, types.Candle{
+			Coin:     coin,
+			Quote:    quote,
+			Interval: interval,
+			OpenTime: openTime,
+			Open:     row[1],
+			High:     row[2],
+			Low:      row[3],
+			Close:    row[4],
+			Source:   Name,
+		})
+	}
+	return candles, nil
+}
+
+// FetchCoinList получает справочник всех монет через /coins/list
+func (p *Provider) FetchCoinList(ctx context.Context) ([]types.Coin, error) {
+	body, err := p.get(ctx, "/coins/list", nil)
+	if err != nil {
+		return nil, err
+	}
+	var coins []types.Coin
+	if err := json.Unmarshal(body, &coins); err != nil {
+		return nil, fmt.Errorf("decoding error JSON: %w", err)
+	}
+	return coins, nil
+}
+
+// parseNumber разбирает число, которое API может вернуть числом или строкой
+func parseNumber(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	default:
+		return 0, fmt.Errorf("unsupported type %T", v)
+	}
+}
+
+// optionalNumber разбирает необязательное поле ответа, отсутствующее или null значение дает nil
+func optionalNumber(value interface{}) *float64 {
+	if value == nil {
+		return nil
+	}
+	number, err := parseNumber(value)
+	if err != nil {
+		return nil
+	}
+	return &number
+}
+
+// get выполняет GET запрос к API CoinGecko с ключом тарифа и возвращает тело ответа
+func (p *Provider) get(ctx context.Context, path string, query url.Values) ([]byte, error) {
+	endpoint := p.baseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request to CoinGecko: %w", err)
+	}
+	if p.keyHeader != "" && p.apiKey != "" {
+		req.Header.Set(p.keyHeader, p.apiKey)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request error to CoinGecko: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, fmt.Errorf("coingecko rate limit exceeded (retry after %q)", resp.Header.Get("Retry-After"))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the API response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected CoinGecko status %d: %s", resp.StatusCode, body)
+	}
+	return body, nil
+}
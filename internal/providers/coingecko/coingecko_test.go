@@ -0,0 +1,162 @@
+package coingecko
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// chunkStandIn локальная замена /simple/price: отвечает ценой 1 на каждую монету,
+// а на запросы с монетами из failing - ошибкой 500. Запоминает состав запросов и их наибольшую параллельность.
+type chunkStandIn struct {
+	failing map[string]bool
+
+	mu       sync.Mutex
+	requests [][]string
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func (s *chunkStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	current := s.inFlight.Add(1)
+	defer s.inFlight.Add(-1)
+	for {
+		peak := s.peak.Load()
+		if current <= peak || s.peak.CompareAndSwap(peak, current) {
+			break
+		}
+	}
+	// Запросы держатся открытыми, чтобы параллельные части успели пересечься
+	time.Sleep(20 * time.Millisecond)
+
+	ids := strings.Split(r.URL.Query().Get("ids"), ",")
+	s.mu.Lock()
+	s.requests = append(s.requests, ids)
+	s.mu.Unlock()
+
+	var entries []string
+	for _, id := range ids {
+		if s.failing[id] {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		entries = append(entries, fmt.Sprintf(`%q:{"usd":1,"last_updated_at":1700000000}`, id))
+	}
+	_, _ = w.Write([]byte("{" + strings.Join(entries, ",") + "}"))
+}
+
+// captureLog перенаправляет стандартный журнал в буфер на время теста
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return &buf
+}
+
+func TestFetchPricesChunks(t *testing.T) {
+	coins := []string{"a", "b", "c", "d", "e"}
+	tests := []struct {
+		name        string
+		failing     []string
+		wantCoins   []string
+		wantErr     bool
+		wantLogged  string
+		wantChunks  [][]string
+		wantWorkers int32
+	}{
+		{
+			name:        "all chunks succeed",
+			wantCoins:   coins,
+			wantChunks:  [][]string{{"a", "b"}, {"c", "d"}, {"e"}},
+			wantWorkers: 2,
+		},
+		{
+			name:        "one chunk fails",
+			failing:     []string{"c"},
+			wantCoins:   []string{"a", "b", "e"},
+			wantLogged:  "1 of 3 chunks failed",
+			wantChunks:  [][]string{{"a", "b"}, {"c", "d"}, {"e"}},
+			wantWorkers: 2,
+		},
+		{
+			name:        "all chunks fail",
+			failing:     coins,
+			wantErr:     true,
+			wantChunks:  [][]string{{"a", "b"}, {"c", "d"}, {"e"}},
+			wantWorkers: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLog(t)
+			standIn := &chunkStandIn{failing: map[string]bool{}}
+			for _, coin := range tt.failing {
+				standIn.failing[coin] = true
+			}
+			server := httptest.NewServer(standIn)
+			defer server.Close()
+
+			provider := New(Settings{BaseURL: server.URL, ChunkSize: 2, ChunkWorkers: 2}, server.Client())
+			quotes, err := provider.FetchPrices(context.Background(), coins)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FetchPrices() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "500") {
+				t.Errorf("error %q does not carry the chunk status", err)
+			}
+
+			var got []string
+			for _, quote := range quotes {
+				got = append(got, quote.Coin)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.wantCoins) {
+				t.Errorf("quotes for %v, want %v", got, tt.wantCoins)
+			}
+			if tt.wantLogged != "" {
+				if !strings.Contains(logs.String(), tt.wantLogged) || !strings.Contains(logs.String(), "chunk 2/3 (2 coins, c..d)") {
+					t.Errorf("partial failure is not logged: %s", logs.String())
+				}
+			}
+
+			sort.Slice(standIn.requests, func(i, j int) bool { return standIn.requests[i][0] < standIn.requests[j][0] })
+			if !reflect.DeepEqual(standIn.requests, tt.wantChunks) {
+				t.Errorf("requested chunks %v, want %v", standIn.requests, tt.wantChunks)
+			}
+			if peak := standIn.peak.Load(); peak > tt.wantWorkers {
+				t.Errorf("%d chunks in flight, want at most %d workers", peak, tt.wantWorkers)
+			}
+		})
+	}
+}
+
+func TestFetchPricesSingleWorker(t *testing.T) {
+	captureLog(t)
+	standIn := &chunkStandIn{failing: map[string]bool{}}
+	server := httptest.NewServer(standIn)
+	defer server.Close()
+
+	provider := New(Settings{BaseURL: server.URL, ChunkSize: 1, ChunkWorkers: 1}, server.Client())
+	quotes, err := provider.FetchPrices(context.Background(), []string{"a", "b", "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quotes) != 3 {
+		t.Fatalf("got %d quotes, want 3", len(quotes))
+	}
+	if peak := standIn.peak.Load(); peak != 1 {
+		t.Fatalf("%d chunks in flight with one worker", peak)
+	}
+}
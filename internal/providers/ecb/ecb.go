@@ -0,0 +1,109 @@
+package ecb
+
+import (
+	"CryptoPriceCollection/internal/types"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Name название поставщика в конфигурации
+const Name = "ecb"
+
+// DefaultURL адрес ежедневных курсов ЕЦБ по умолчанию
+const DefaultURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+
+type Provider struct {
+	client *http.Client
+	url    string
+}
+
+// envelope ответ eurofxref-daily.xml: курсы валют за 1 EUR
+type envelope struct {
+	Cube struct {
+		Days []struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string  `xml:"currency,attr"`
+				Rate     float64 `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+func New(url string, client *http.Client) *Provider {
+	if url == "" {
+		url = DefaultURL
+	}
+	return &Provider{
+		client: client,
+		url:    url,
+	}
+}
+
+// Name возвращает название поставщика
+func (p *Provider) Name() string {
+	return Name
+}
+
+// FetchRates получает курсы ЕЦБ и пересчитывает их из EUR в USD
+func (p *Provider) FetchRates(ctx context.Context) ([]types.FXRate, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request to ECB: %w", err)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request error to ECB: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected ECB status %d", resp.StatusCode)
+	}
+	return Parse(resp.Body)
+}
+
+// Parse разбирает eurofxref-daily.xml в курсы за 1 USD
+func Parse(r io.Reader) ([]types.FXRate, error) {
+	var doc envelope
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decoding ECB XML: %w", err)
+	}
+	if len(doc.Cube.Days) == 0 {
+		return nil, fmt.Errorf("no rates in ECB XML")
+	}
+	day := doc.Cube.Days[0]
+	date, err := time.Parse(time.DateOnly, day.Time)
+	if err != nil {
+		return nil, fmt.Errorf("parsing ECB date %q: %w", day.Time, err)
+	}
+
+	perEUR := make(map[string]float64, len(day.Rates)+1)
+	for _, rate := range day.Rates {
+		perEUR[strings.ToLower(rate.Currency)] = rate.Rate
+	}
+	perEUR["eur"] = 1
+	usd, ok := perEUR[types.DefaultQuote]
+	if !ok || usd == 0 {
+		return nil, fmt.Errorf("no USD rate in ECB XML")
+	}
+
+	rates := make([]types.FXRate, 0, len(perEUR))
+	for currency, rate := range perEUR {
+		if currency == types.DefaultQuote || rate == 0 {
+			continue
+		}
+		rates = append(rates, types.FXRate{
+			Base:      types.DefaultQuote,
+			Currency:  currency,
+			Rate:      rate / usd,
+			Timestamp: date.Unix(),
+			Source:    Name,
+		})
+	}
+	return rates, nil
+}
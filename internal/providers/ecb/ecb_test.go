@@ -0,0 +1,92 @@
+package ecb
+
+import (
+	"CryptoPriceCollection/internal/types"
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture string
+		want    map[string]float64
+		wantErr bool
+	}{
+		{
+			name:    "daily rates rebased to USD",
+			fixture: "testdata/eurofxref-daily.xml",
+			want: map[string]float64{
+				"eur": 1 / 1.0780,
+				"jpy": 167.71 / 1.0780,
+				"gbp": 0.86 / 1.0780,
+				"chf": 0.9770 / 1.0780,
+			},
+		},
+		{name: "missing USD rate", fixture: "testdata/no-usd.xml", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := os.Open(tt.fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			rates, err := Parse(f)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %v", rates)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRates(t, rates, tt.want, time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC))
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "not XML", body: "rates"},
+		{name: "no days", body: `<Envelope><Cube></Cube></Envelope>`},
+		{name: "bad date", body: `<Envelope><Cube><Cube time="10.05.2024"><Cube currency="USD" rate="1.07"/></Cube></Cube></Envelope>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rates, err := Parse(strings.NewReader(tt.body)); err == nil {
+				t.Fatalf("expected error, got %v", rates)
+			}
+		})
+	}
+}
+
+func checkRates(t *testing.T, rates []types.FXRate, want map[string]float64, date time.Time) {
+	t.Helper()
+	if len(rates) != len(want) {
+		t.Fatalf("got %d rates, want %d: %v", len(rates), len(want), rates)
+	}
+	for _, rate := range rates {
+		expected, ok := want[rate.Currency]
+		if !ok {
+			t.Fatalf("unexpected currency %q", rate.Currency)
+		}
+		if math.Abs(rate.Rate-expected) > 1e-9 {
+			t.Errorf("%s rate = %v, want %v", rate.Currency, rate.Rate, expected)
+		}
+		if rate.Base != types.DefaultQuote || rate.Source != Name {
+			t.Errorf("%s base/source = %s/%s", rate.Currency, rate.Base, rate.Source)
+		}
+		if rate.Timestamp != date.Unix() {
+			t.Errorf("%s timestamp = %d, want %d", rate.Currency, rate.Timestamp, date.Unix())
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package httpclient
+
+import (
+	"CryptoPriceCollection/internal/providers/ratelimit"
+	"CryptoPriceCollection/internal/providers/recorder"
+	"CryptoPriceCollection/internal/types"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultTimeout = 10 * time.Second
+	defaultBackoff = 500 * time.Millisecond
+	maxBackoff     = 30 * time.Second
+	maxRetryAfter  = time.Minute // дольше Retry-After не ждем, ответ возвращается вызывающему
+)
+
+// retryTransport повторяет неудачные запросы с экспоненциальной паузой и учетом Retry-After
+type retryTransport struct {
+	next       http.RoundTripper
+	limiter    *ratelimit.Limiter // токен берется до начала отсчета таймаута попытки
+	timeout    time.Duration
+	maxRetries int
+	backoff    time.Duration
+}
+
+// New создает HTTP клиент с повторными попытками по настройкам API_TIMEOUT, API_MAX_RETRIES и API_RETRY_BACKOFF.
+// Если задан limiter, через него проходит каждая попытка, включая повторные; ожидание токена ограничено только
+// контекстом запроса, а не таймаутом попытки.
+// API_RECORD_MODE=record дополнительно записывает обмены в файлы, replay отвечает из них без обращения к сети.
+func New(cfg *types.ConfigAPIClient, limiter *ratelimit.Limiter) *http.Client {
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	backoff := time.Duration(cfg.RetryBackoff) * time.Millisecond
+	if backoff <= 0 {
+		backoff = defaultBackoff
+	}
+	maxRetries := cfg.MaxRetries
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+
+	fixturesDir := cfg.FixturesDir
+	if fixturesDir == "" {
+		fixturesDir = recorder.DefaultDir
+	}
+	if cfg.RecordMode == recorder.ModeReplay {
+		return &http.Client{
+			Transport: &recorder.Transport{Mode: recorder.ModeReplay, Match: cfg.ReplayMatch, Dir: fixturesDir},
+		}
+	}
+
+	var transport http.RoundTripper = &retryTransport{
+		next:       http.DefaultTransport,
+		limiter:    limiter,
+		timeout:    timeout,
+		maxRetries: maxRetries,
+		backoff:    backoff,
+	}
+	switch cfg.RecordMode {
+	case "":
+	case recorder.ModeRecord:
+		transport = &recorder.Transport{Mode: recorder.ModeRecord, Dir: fixturesDir, Next: transport}
+	default:
+		log.Printf("Unknown API_RECORD_MODE %q, recording is disabled", cfg.RecordMode)
+	}
+	return &http.Client{Transport: transport}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	// Запрос с телом можно повторить, только если его можно пересоздать
+	retryable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+
+	for attempt := 0; ; attempt++ {
+		if t.limiter != nil {
+			if err := t.limiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+		resp, err := t.attempt(req)
+		if ctx.Err() != nil {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			return nil, ctx.Err()
+		}
+
+		wait, retry := t.shouldRetry(resp, err, attempt)
+		if !retry || !retryable || attempt >= t.maxRetries {
+			return resp, err
+		}
+
+		reason := fmt.Sprint(err)
+		if resp != nil {
+			reason = resp.Status
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+		log.Printf("Attempt %d/%d %s %s failed: %s, retrying in %s",
+			attempt+1, t.maxRetries+1, req.Method, req.URL.Redacted(), reason, wait)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+	}
+}
+
+// attempt выполняет одну попытку с собственным таймаутом
+func (t *retryTransport) attempt(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.next.RoundTrip(req.Clone(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// Таймаут попытки действует до закрытия тела ответа
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// shouldRetry определяет, нужно ли повторять запрос, и паузу перед повтором
+func (t *retryTransport) shouldRetry(resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	if err != nil {
+		return t.delay(attempt), true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		if wait, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			if wait > maxRetryAfter {
+				return 0, false
+			}
+			return wait, true
+		}
+		return t.delay(attempt), true
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return t.delay(attempt), true
+	default:
+		return 0, false
+	}
+}
+
+// delay экспоненциальная пауза с полным джиттером
+func (t *retryTransport) delay(attempt int) time.Duration {
+	ceiling := t.backoff << attempt
+	if ceiling <= 0 || ceiling > maxBackoff {
+		ceiling = maxBackoff
+	}
+	return time.Duration(rand.Int63n(int64(ceiling)) + 1)
+}
+
+// retryAfter разбирает заголовок Retry-After в секундах или в формате HTTP-даты
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+// cancelBody освобождает контекст попытки при закрытии тела ответа
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
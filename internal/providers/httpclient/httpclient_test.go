@@ -0,0 +1,279 @@
+package httpclient
+
+import (
+	"CryptoPriceCollection/internal/providers/ratelimit"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Токен пополняется дольше таймаута попытки, но ожидание токена не должно съедать таймаут
+func TestSlowLimiterDoesNotTimeOutAttempts(t *testing.T) {
+	var hits atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// 600 запросов в минуту - токен раз в 100 мс при таймауте попытки 30 мс
+	client := &http.Client{Transport: &retryTransport{
+		next:    http.DefaultTransport,
+		limiter: ratelimit.NewPerMinute(600, 1),
+		timeout: 30 * time.Millisecond,
+		backoff: time.Millisecond,
+	}}
+
+	// Запросы конкурируют за один ограничитель, как части списка монет и загрузка истории
+	const requests = 4
+	var wg sync.WaitGroup
+	errs := make(chan error, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				errs <- err
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("request failed: %v", err)
+	}
+	if got := hits.Load(); got != requests {
+		t.Fatalf("server got %d requests, want %d", got, requests)
+	}
+}
+
+// newRetryTransport транспорт с короткими паузами для тестов
+func newRetryTransport(maxRetries int) *retryTransport {
+	return &retryTransport{
+		next:       http.DefaultTransport,
+		timeout:    time.Second,
+		maxRetries: maxRetries,
+		backoff:    time.Millisecond,
+	}
+}
+
+func TestRetryAttempts(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int // ответы по очереди, последний повторяется
+		header       http.Header
+		maxRetries   int
+		wantAttempts int
+		wantStatus   int
+	}{
+		{name: "success", statuses: []int{200}, maxRetries: 3, wantAttempts: 1, wantStatus: 200},
+		{name: "server errors then success", statuses: []int{500, 502, 504, 200}, maxRetries: 3, wantAttempts: 4, wantStatus: 200},
+		{name: "unavailable then success", statuses: []int{503, 200}, maxRetries: 3, wantAttempts: 2, wantStatus: 200},
+		{name: "rate limited then success", statuses: []int{429, 200}, header: http.Header{"Retry-After": {"0"}}, maxRetries: 3, wantAttempts: 2, wantStatus: 200},
+		{name: "retries exhausted", statuses: []int{500}, maxRetries: 2, wantAttempts: 3, wantStatus: 500},
+		{name: "no retries configured", statuses: []int{500}, maxRetries: 0, wantAttempts: 1, wantStatus: 500},
+		{name: "client error is not retried", statuses: []int{404}, maxRetries: 3, wantAttempts: 1, wantStatus: 404},
+		{name: "too long Retry-After is returned", statuses: []int{429}, header: http.Header{"Retry-After": {"3600"}}, maxRetries: 3, wantAttempts: 1, wantStatus: 429},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int64
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(attempts.Add(1))
+				status := tt.statuses[min(n, len(tt.statuses))-1]
+				if status != http.StatusOK {
+					for name, values := range tt.header {
+						w.Header()[name] = values
+					}
+				}
+				w.WriteHeader(status)
+			}))
+			defer server.Close()
+
+			client := &http.Client{Transport: newRetryTransport(tt.maxRetries)}
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := int(attempts.Load()); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestRetryWaitsRetryAfter(t *testing.T) {
+	var attempts []time.Time
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts = append(attempts, time.Now())
+		if len(attempts) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: newRetryTransport(1)}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if len(attempts) != 2 {
+		t.Fatalf("attempts = %d, want 2", len(attempts))
+	}
+	if gap := attempts[1].Sub(attempts[0]); gap < time.Second {
+		t.Fatalf("retried after %s, want at least Retry-After 1s", gap)
+	}
+}
+
+func TestRetryReplaysBody(t *testing.T) {
+	var bodies []string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		bodies = append(bodies, string(body))
+		if len(bodies) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		body       io.Reader
+		wantBodies []string
+	}{
+		// http.NewRequest задает GetBody для strings.Reader, поэтому тело отправляется заново
+		{name: "replayable body", body: strings.NewReader("payload"), wantBodies: []string{"payload", "payload", "payload"}},
+		// Тело без GetBody нельзя пересоздать, поэтому запрос не повторяется
+		{name: "one-shot body", body: io.MultiReader(strings.NewReader("payload")), wantBodies: []string{"payload"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mu.Lock()
+			bodies = nil
+			mu.Unlock()
+
+			client := &http.Client{Transport: newRetryTransport(3)}
+			resp, err := client.Post(server.URL, "text/plain", tt.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if !reflect.DeepEqual(bodies, tt.wantBodies) {
+				t.Fatalf("server got bodies %q, want %q", bodies, tt.wantBodies)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	future := time.Now().Add(90 * time.Second).UTC().Format(http.TimeFormat)
+	past := time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)
+	tests := []struct {
+		name    string
+		value   string
+		wantMin time.Duration
+		wantMax time.Duration
+		wantOK  bool
+	}{
+		{name: "empty", value: ""},
+		{name: "seconds", value: "120", wantMin: 2 * time.Minute, wantMax: 2 * time.Minute, wantOK: true},
+		{name: "zero seconds", value: "0", wantOK: true},
+		{name: "negative seconds", value: "-5"},
+		{name: "http date", value: future, wantMin: 88 * time.Second, wantMax: 90 * time.Second, wantOK: true},
+		{name: "past http date", value: past, wantOK: true},
+		{name: "garbage", value: "soon"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := retryAfter(tt.value)
+			if ok != tt.wantOK {
+				t.Fatalf("retryAfter(%q) ok = %v, want %v", tt.value, ok, tt.wantOK)
+			}
+			if got < tt.wantMin || got > tt.wantMax {
+				t.Fatalf("retryAfter(%q) = %s, want between %s and %s", tt.value, got, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestDelayIsCapped(t *testing.T) {
+	tr := &retryTransport{backoff: 500 * time.Millisecond}
+	tests := []struct {
+		attempt int
+		ceiling time.Duration
+	}{
+		{attempt: 0, ceiling: 500 * time.Millisecond},
+		{attempt: 1, ceiling: time.Second},
+		{attempt: 3, ceiling: 4 * time.Second},
+		{attempt: 10, ceiling: maxBackoff},
+		// Сдвиг переполняет Duration, пауза все равно ограничена сверху
+		{attempt: 70, ceiling: maxBackoff},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 200; i++ {
+			if got := tr.delay(tt.attempt); got <= 0 || got > tt.ceiling {
+				t.Fatalf("delay(%d) = %s, want in (0, %s]", tt.attempt, got, tt.ceiling)
+			}
+		}
+	}
+}
+
+func TestShouldRetryRetryAfter(t *testing.T) {
+	tr := &retryTransport{backoff: time.Millisecond}
+	tests := []struct {
+		name      string
+		status    int
+		header    string
+		wantRetry bool
+		wantWait  time.Duration
+	}{
+		{name: "429 with seconds", status: 429, header: "7", wantRetry: true, wantWait: 7 * time.Second},
+		{name: "503 with seconds", status: 503, header: "2", wantRetry: true, wantWait: 2 * time.Second},
+		{name: "429 over the limit", status: 429, header: "61", wantRetry: false},
+		{name: "500 ignores Retry-After", status: 500, header: "7", wantRetry: true},
+		{name: "400", status: 400, wantRetry: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+			if tt.header != "" {
+				resp.Header.Set("Retry-After", tt.header)
+			}
+			wait, retry := tr.shouldRetry(resp, nil, 0)
+			if retry != tt.wantRetry {
+				t.Fatalf("retry = %v, want %v", retry, tt.wantRetry)
+			}
+			if tt.wantWait > 0 && wait != tt.wantWait {
+				t.Fatalf("wait = %s, want %s", wait, tt.wantWait)
+			}
+			if tt.wantWait == 0 && retry && wait > time.Millisecond {
+				t.Fatalf("wait = %s, want backoff under 1ms", wait)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package kraken
+
+import (
+	"CryptoPriceCollection/internal/types"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Name название поставщика в конфигурации
+const Name = "kraken"
+
+// DefaultBaseURL адрес REST API Kraken по умолчанию
+const DefaultBaseURL = "https://api.kraken.com"
+
+type Provider struct {
+	client  *http.Client
+	baseURL string
+	pairs   map[string]string // id монеты -> пара Kraken (XXBTZUSD)
+	now     func() time.Time  // время котировок: тикер Kraken не сообщает время цены
+}
+
+// usdSuffixes окончания пар, котируемых в USD (XXBTZUSD) и долларовых стейблкоинах (XBTUSDT)
+var usdSuffixes = []string{"USD", "USDT", "USDC"}
+
+// tickerResponse ответ /0/public/Ticker
+type tickerResponse struct {
+	Error  []string                `json:"error"`
+	Result map[string]tickerResult `json:"result"`
+}
+
+// tickerResult данные по одной паре: массивы вида [сегодня, последние 24 часа]
+type tickerResult struct {
+	Last   []string `json:"c"` // [цена, объем] последней сделки
+	Volume []string `json:"v"` // объем в базовой валюте
+	VWAP   []string `json:"p"` // средневзвешенная цена
+}
+
+func New(baseURL string, pairs map[string]string, client *http.Client) *Provider {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Provider{
+		client:  client,
+		baseURL: baseURL,
+		pairs:   pairs,
+		now:     time.Now,
+	}
+}
+
+// CheckPairs проверяет, что все пары котируются в USD: цены Kraken сохраняются с валютой котировки usd
+func CheckPairs(pairs map[string]string) error {
+	coins := make([]string, 0, len(pairs))
+	for coin := range pairs {
+		coins = append(coins, coin)
+	}
+	sort.Strings(coins)
+	for _, coin := range coins {
+		if !hasUSDSuffix(strings.ToUpper(pairs[coin])) {
+			return fmt.Errorf("pair %s for %s is not quoted in %s", pairs[coin], coin, types.DefaultQuote)
+		}
+	}
+	return nil
+}
+
+// hasUSDSuffix проверяет, что пара оканчивается валютой, приравниваемой к USD
+func hasUSDSuffix(pair string) bool {
+	for _, suffix := range usdSuffixes {
+		if strings.HasSuffix(pair, suffix) && len(pair) > len(suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetClock задает источник текущего времени, например фиксированное время при воспроизведении записанных обменов
+func (p *Provider) SetClock(now func() time.Time) {
+	p.now = now
+}
+
+// Name возвращает название поставщика
+func (p *Provider) Name() string {
+	return Name
+}
+
+// FetchPrices получает цены всех пар одним запросом к /0/public/Ticker
+func (p *Provider) FetchPrices(ctx context.Context, coins []string) ([]types.Quote, error) {
+	byPair := make(map[string]string, len(coins))
+	pairs := make([]string, 0, len(coins))
+	for _, coin := range coins {
+		pair, ok := p.pairs[coin]
+		if !ok {
+			log.Printf("No Kraken pair configured for %s", coin)
+			continue
+		}
+		byPair[pair] = coin
+		pairs = append(pairs, pair)
+	}
+	// Немапленные монеты - не сбой биржи, иначе автомат защиты отключил бы исправного поставщика
+	if len(pairs) == 0 {
+		log.Printf("No Kraken pairs configured for requested coins %v", coins)
+		return nil, nil
+	}
+
+	endpoint := fmt.Sprintf("%s/0/public/Ticker?pair=%s", p.baseURL, url.QueryEscape(strings.Join(pairs, ",")))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request to Kraken: %w", err)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request error to Kraken: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the Kraken response: %w", err)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, fmt.Errorf("kraken rate limit exceeded (retry after %q)", resp.Header.Get("Retry-After"))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected Kraken status %d", resp.StatusCode)
+	}
+
+	var result tickerResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("decoding error JSON: %w", err)
+	}
+	// Kraken сообщает об ошибках, в том числе о лимитах, в поле error при статусе 200
+	if len(result.Error) > 0 {
+		for _, e := range result.Error {
+			if strings.Contains(e, "Rate limit") || strings.Contains(e, "Too many requests") {
+				return nil, fmt.Errorf("kraken rate limit exceeded: %s", e)
+			}
+		}
+		if len(result.Result) == 0 {
+			return nil, fmt.Errorf("kraken error: %s", strings.Join(result.Error, "; "))
+		}
+		log.Printf("Kraken returned warnings: %s", strings.Join(result.Error, "; "))
+	}
+
+	timestamp := p.now().Unix()
+	quotes := make([]types.Quote, 0, len(result.Result))
+	for pair, data := range result.Result {
+		coin, ok := byPair[pair]
+		if !ok {
+			log.Printf("Unexpected Kraken pair %s in response, check KRAKEN_PAIRS", pair)
+			continue
+		}
+		if len(data.Last) == 0 {
+			log.Printf("No last trade price for Kraken pair %s", pair)
+			continue
+		}
+		price, err := strconv.ParseFloat(data.Last[0], 64)
+		if err != nil {
+			log.Printf("Error converting the Kraken price for %s: %v", pair, err)
+			continue
+		}
+		quote := types.Quote{
+			Coin:      coin,
+			Quote:     types.DefaultQuote,
+			Price:     price,
+			Timestamp: timestamp,
+			Source:    Name,
+		}
+		// Объем за 24 часа в валюте котировки: базовый объем, умноженный на VWAP
+		if len(data.Volume) > 1 && len(data.VWAP) > 1 {
+			volume, errVol := strconv.ParseFloat(data.Volume[1], 64)
+			vwap, errVWAP := strconv.ParseFloat(data.VWAP[1], 64)
+			if errVol == nil && errVWAP == nil {
+				quoteVolume := volume * vwap
+				quote.Volume24h = &quoteVolume
+			}
+		}
+		quotes = append(quotes, quote)
+	}
+	return quotes, nil
+}
@@ -0,0 +1,129 @@
+package kraken
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+)
+
+// tickerBody ответ /0/public/Ticker: Kraken возвращает пары под собственными ключами (XXBTZUSD), а не под запрошенными
+const tickerBody = `{"error":[],"result":{
+	"XXBTZUSD":{"c":["64000.1","0.01"],"v":["100","200"],"p":["63000","63500"]},
+	"XETHZUSD":{"c":["3100.5","1.2"],"v":["1000","2000"],"p":["3000","3050"]}
+}}`
+
+func newStandIn(t *testing.T, status int, body string) (*Provider, *[]string) {
+	t.Helper()
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/0/public/Ticker" {
+			http.NotFound(w, r)
+			return
+		}
+		requested = append(requested, r.URL.Query().Get("pair"))
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	provider := New(server.URL, map[string]string{"bitcoin": "XXBTZUSD", "ethereum": "XETHZUSD"}, server.Client())
+	provider.SetClock(func() time.Time { return time.Unix(1700000000, 0) })
+	return provider, &requested
+}
+
+func TestFetchPricesRemapsPairs(t *testing.T) {
+	provider, requested := newStandIn(t, http.StatusOK, tickerBody)
+
+	quotes, err := provider.FetchPrices(context.Background(), []string{"bitcoin", "ethereum", "dogecoin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*requested) != 1 || (*requested)[0] != "XXBTZUSD,XETHZUSD" {
+		t.Fatalf("requested pairs %v, want one request for XXBTZUSD,XETHZUSD", *requested)
+	}
+
+	sort.Slice(quotes, func(i, j int) bool { return quotes[i].Coin < quotes[j].Coin })
+	if len(quotes) != 2 {
+		t.Fatalf("got %d quotes, want 2: %+v", len(quotes), quotes)
+	}
+	tests := []struct {
+		coin   string
+		price  float64
+		volume float64
+	}{
+		{coin: "bitcoin", price: 64000.1, volume: 200 * 63500},
+		{coin: "ethereum", price: 3100.5, volume: 2000 * 3050},
+	}
+	for i, tt := range tests {
+		quote := quotes[i]
+		if quote.Coin != tt.coin || quote.Price != tt.price || quote.Quote != "usd" || quote.Source != Name {
+			t.Errorf("quote %d = %+v, want %s at %v usd", i, quote, tt.coin, tt.price)
+		}
+		if quote.Timestamp != 1700000000 {
+			t.Errorf("%s timestamp = %d, want the injected clock", tt.coin, quote.Timestamp)
+		}
+		if quote.Volume24h == nil || *quote.Volume24h != tt.volume {
+			t.Errorf("%s volume = %v, want %v", tt.coin, quote.Volume24h, tt.volume)
+		}
+	}
+}
+
+func TestFetchPricesErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr bool
+		want    int
+	}{
+		{name: "unknown pair key is skipped", status: http.StatusOK, body: `{"error":[],"result":{"XBTUSD":{"c":["1","1"]}}}`, want: 0},
+		{name: "api error", status: http.StatusOK, body: `{"error":["EQuery:Unknown asset pair"],"result":{}}`, wantErr: true},
+		{name: "rate limit in body", status: http.StatusOK, body: `{"error":["EAPI:Rate limit exceeded"],"result":{}}`, wantErr: true},
+		{name: "warning with data", status: http.StatusOK, body: `{"error":["EGeneral:Warning"],"result":{"XXBTZUSD":{"c":["1","1"]}}}`, want: 1},
+		{name: "http error", status: http.StatusBadGateway, body: ``, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, _ := newStandIn(t, tt.status, tt.body)
+			quotes, err := provider.FetchPrices(context.Background(), []string{"bitcoin"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FetchPrices() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(quotes) != tt.want {
+				t.Fatalf("got %d quotes, want %d", len(quotes), tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchPricesUnmappedCoins(t *testing.T) {
+	provider, requested := newStandIn(t, http.StatusOK, tickerBody)
+	quotes, err := provider.FetchPrices(context.Background(), []string{"dogecoin"})
+	if err != nil || quotes != nil {
+		t.Fatalf("FetchPrices() = %v, %v; want no quotes and no error", quotes, err)
+	}
+	if len(*requested) != 0 {
+		t.Fatalf("unmapped coins must not reach the API, got %v", *requested)
+	}
+}
+
+func TestCheckPairs(t *testing.T) {
+	tests := []struct {
+		pairs   map[string]string
+		wantErr bool
+	}{
+		{pairs: map[string]string{"bitcoin": "XXBTZUSD", "ethereum": "XETHZUSD"}},
+		{pairs: map[string]string{"bitcoin": "XBTUSDT", "ethereum": "ethusdc"}},
+		{pairs: map[string]string{"bitcoin": "XXBTZEUR"}, wantErr: true},
+		{pairs: map[string]string{"ethereum": "XETHXXBT"}, wantErr: true},
+		{pairs: map[string]string{"usd": "USD"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := CheckPairs(tt.pairs); (err != nil) != tt.wantErr {
+			t.Errorf("CheckPairs(%v) error = %v, wantErr %v", tt.pairs, err, tt.wantErr)
+		}
+	}
+}
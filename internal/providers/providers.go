@@ -0,0 +1,265 @@
+package providers
+
+import (
+	"CryptoPriceCollection/internal/providers/binance"
+	"CryptoPriceCollection/internal/providers/breaker"
+	"CryptoPriceCollection/internal/providers/cbr"
+	"CryptoPriceCollection/internal/providers/coinbase"
+	"CryptoPriceCollection/internal/providers/coingecko"
+	"CryptoPriceCollection/internal/providers/ecb"
+	"CryptoPriceCollection/internal/providers/httpclient"
+	"CryptoPriceCollection/internal/providers/kraken"
+	"CryptoPriceCollection/internal/providers/ratelimit"
+	"CryptoPriceCollection/internal/providers/synthetic"
+	"CryptoPriceCollection/internal/types"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// defaultCoinGeckoCallsPerMinute лимит бесплатного публичного API с запасом
+const defaultCoinGeckoCallsPerMinute = 30
+
+type PriceProvider interface {
+	Name() string                                                           // Название поставщика
+	FetchPrices(ctx context.Context, coins []string) ([]types.Quote, error) // Получение цен на список монет
+}
+
+type PriceStreamer interface {
+	Name() string                                                                              // Название поставщика
+	StreamPrices(ctx context.Context, watchlist <-chan []string, out chan<- types.Quote) error // Потоковое получение цен по актуальному списку монет
+}
+
+type HistoryProvider interface {
+	Name() string                                                                              // Название поставщика
+	FetchRange(ctx context.Context, coin, quote string, from, to int64) ([]types.Quote, error) // Получение истории цен за интервал
+}
+
+type CandleProvider interface {
+	Name() string                                                                                           // Название поставщика
+	FetchCandles(ctx context.Context, coin, quote, interval string, from, to int64) ([]types.Candle, error) // Получение свечей за интервал
+}
+
+type CoinListProvider interface {
+	Name() string                                            // Название поставщика
+	FetchCoinList(ctx context.Context) ([]types.Coin, error) // Получение справочника монет
+}
+
+type FXProvider interface {
+	Name() string                                           // Название поставщика
+	FetchRates(ctx context.Context) ([]types.FXRate, error) // Получение курсов фиатных валют к USD
+}
+
+type StatusReporter interface {
+	Status() types.ProviderStatus // Состояние автоматического выключателя поставщика
+}
+
+// New создает поставщиков цен в порядке, указанном в конфигурации, каждый за своим автоматическим выключателем.
+// Клиент CoinGecko передается снаружи, чтобы его ограничитель запросов был общим с загрузкой истории.
+func New(cfgProviders *types.ConfigProviders, cfgAPI *types.ConfigAPIClient, coinGecko *coingecko.Provider) ([]PriceProvider, error) {
+	names := ParseList(cfgProviders.Providers)
+	if len(names) == 0 {
+		names = []string{coingecko.Name}
+	}
+
+	client := httpclient.New(cfgAPI, nil)
+
+	priceProviders := make([]PriceProvider, 0, len(names))
+	for _, name := range names {
+		switch strings.ToLower(name) {
+		case coingecko.Name:
+			priceProviders = append(priceProviders, coinGecko)
+		case binance.Name:
+			symbols, err := binanceSymbols(cfgProviders)
+			if err != nil {
+				return nil, err
+			}
+			priceProviders = append(priceProviders, binance.New(cfgProviders.BinanceBaseURL, symbols, client))
+		case coinbase.Name:
+			products, err := ParseMapping(cfgProviders.CoinbaseProducts)
+			if err == nil {
+				err = coinbase.CheckProducts(products)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("coinbase products: %w", err)
+			}
+			priceProviders = append(priceProviders, coinbase.New(cfgProviders.CoinbaseBaseURL, products, client))
+		case kraken.Name:
+			pairs, err := ParseMapping(cfgProviders.KrakenPairs)
+			if err == nil {
+				err = kraken.CheckPairs(pairs)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("kraken pairs: %w", err)
+			}
+			priceProviders = append(priceProviders, kraken.New(cfgProviders.KrakenBaseURL, pairs, client))
+		case synthetic.Name:
+			provider, err := NewSynthetic(cfgProviders)
+			if err != nil {
+				return nil, err
+			}
+			priceProviders = append(priceProviders, provider)
+		default:
+			return nil, fmt.Errorf("unknown price provider %q", name)
+		}
+	}
+
+	settings := breaker.Settings{
+		FailureThreshold:  cfgProviders.BreakerFailureThreshold,
+		OpenTimeout:       time.Duration(cfgProviders.BreakerOpenTimeout) * time.Second,
+		HalfOpenSuccesses: cfgProviders.BreakerHalfOpenSuccesses,
+	}
+	for i, provider := range priceProviders {
+		priceProviders[i] = breaker.New(provider, settings)
+	}
+	return priceProviders, nil
+}
+
+// binanceSymbols разбирает соответствие монет символам Binance; символы должны котироваться в USD
+func binanceSymbols(cfgProviders *types.ConfigProviders) (map[string]string, error) {
+	symbols, err := ParseMapping(cfgProviders.BinanceSymbols)
+	if err == nil {
+		err = binance.CheckSymbols(symbols)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("binance symbols: %w", err)
+	}
+	return symbols, nil
+}
+
+// NewCoinGecko создает клиент CoinGecko с собственным ограничителем запросов по тарифу
+func NewCoinGecko(cfgProviders *types.ConfigProviders, cfgAPI *types.ConfigAPIClient) *coingecko.Provider {
+	callsPerMinute := cfgProviders.CoinGeckoCallsPerMinute
+	if callsPerMinute <= 0 {
+		callsPerMinute = defaultCoinGeckoCallsPerMinute
+	}
+	limiter := ratelimit.NewPerMinute(callsPerMinute, 1)
+	client := httpclient.New(cfgAPI, limiter)
+	return coingecko.New(coingecko.Settings{
+		BaseURL:      cfgAPI.BaseURL,
+		Plan:         strings.ToLower(cfgProviders.CoinGeckoPlan),
+		APIKey:       cfgProviders.CoinGeckoAPIKey,
+		ChunkSize:    cfgProviders.CoinGeckoChunkSize,
+		ChunkWorkers: cfgProviders.CoinGeckoChunkWorkers,
+		VsCurrencies: QuoteCurrencies(cfgProviders),
+	}, client)
+}
+
+// NewSynthetic создает генератор синтетических цен для демонстрации и нагрузочных тестов
+func NewSynthetic(cfgProviders *types.ConfigProviders) (*synthetic.Provider, error) {
+	provider, err := synthetic.New(synthetic.Settings{
+		Model:        cfgProviders.SyntheticModel,
+		Seed:         cfgProviders.SyntheticSeed,
+		Volatility:   cfgProviders.SyntheticVolatility,
+		Drift:        cfgProviders.SyntheticDrift,
+		StartPrice:   cfgProviders.SyntheticStartPrice,
+		TickInterval: time.Duration(cfgProviders.SyntheticTickInterval) * time.Millisecond,
+		ExtraCoins:   cfgProviders.SyntheticCoins,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("synthetic provider: %w", err)
+	}
+	return provider, nil
+}
+
+// NewCandleProvider создает поставщика свечей из конфигурации, по умолчанию Binance
+func NewCandleProvider(cfgProviders *types.ConfigProviders, cfgCandles *types.ConfigCandles, cfgAPI *types.ConfigAPIClient, coinGecko *coingecko.Provider) (CandleProvider, error) {
+	switch strings.ToLower(cfgCandles.Provider) {
+	case "", binance.Name:
+		symbols, err := binanceSymbols(cfgProviders)
+		if err != nil {
+			return nil, err
+		}
+		return binance.New(cfgProviders.BinanceBaseURL, symbols, httpclient.New(cfgAPI, nil)), nil
+	case coingecko.Name:
+		return coinGecko, nil
+	default:
+		return nil, fmt.Errorf("unknown candle provider %q", cfgCandles.Provider)
+	}
+}
+
+// NewFX создает поставщиков курсов фиатных валют из конфигурации
+func NewFX(cfgFX *types.ConfigFX, cfgAPI *types.ConfigAPIClient) ([]FXProvider, error) {
+	client := httpclient.New(cfgAPI, nil)
+
+	var fxProviders []FXProvider
+	for _, name := range ParseList(cfgFX.Providers) {
+		switch strings.ToLower(name) {
+		case ecb.Name:
+			fxProviders = append(fxProviders, ecb.New(cfgFX.ECBURL, client))
+		case cbr.Name:
+			fxProviders = append(fxProviders, cbr.New(cfgFX.CBRURL, client))
+		default:
+			return nil, fmt.Errorf("unknown FX provider %q", name)
+		}
+	}
+	return fxProviders, nil
+}
+
+// NewStreamers создает поставщиков потоковых котировок из конфигурации
+func NewStreamers(cfgProviders *types.ConfigProviders) ([]PriceStreamer, error) {
+	names := ParseList(cfgProviders.StreamProviders)
+	if len(names) == 0 {
+		names = []string{binance.Name}
+	}
+
+	streamers := make([]PriceStreamer, 0, len(names))
+	for _, name := range names {
+		switch strings.ToLower(name) {
+		case binance.Name:
+			symbols, err := binanceSymbols(cfgProviders)
+			if err != nil {
+				return nil, err
+			}
+			streamers = append(streamers, binance.NewStreamer(cfgProviders.BinanceStreamURL, symbols))
+		case synthetic.Name:
+			streamer, err := NewSynthetic(cfgProviders)
+			if err != nil {
+				return nil, err
+			}
+			streamers = append(streamers, streamer)
+		default:
+			return nil, fmt.Errorf("unknown stream provider %q", name)
+		}
+	}
+	return streamers, nil
+}
+
+// QuoteCurrencies возвращает валюты котировки из конфигурации, по умолчанию usd
+func QuoteCurrencies(cfgProviders *types.ConfigProviders) []string {
+	var currencies []string
+	for _, currency := range ParseList(cfgProviders.QuoteCurrencies) {
+		currencies = append(currencies, strings.ToLower(currency))
+	}
+	if len(currencies) == 0 {
+		currencies = []string{types.DefaultQuote}
+	}
+	return currencies
+}
+
+// ParseList разбирает список значений, разделенных запятыми
+func ParseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// ParseMapping разбирает соответствие вида key1:value1,key2:value2
+func ParseMapping(value string) (map[string]string, error) {
+	mapping := make(map[string]string)
+	for _, item := range ParseList(value) {
+		key, val, ok := strings.Cut(item, ":")
+		key, val = strings.TrimSpace(key), strings.TrimSpace(val)
+		if !ok || key == "" || val == "" {
+			return nil, fmt.Errorf("invalid mapping entry %q", item)
+		}
+		mapping[key] = val
+	}
+	return mapping, nil
+}
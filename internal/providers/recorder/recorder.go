@@ -0,0 +1,251 @@
+package recorder
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Режимы работы
+const (
+	ModeRecord = "record" // запросы уходят в сеть, обмены дописываются в файлы
+	ModeReplay = "replay" // ответы берутся только из файлов, сеть не используется
+)
+
+// Способы сопоставления запроса с записанным обменом при воспроизведении. В обоих случаях запрос ищется
+// по методу и URL, поэтому параллельные запросы (например, части списка монет CoinGecko) могут приходить
+// в любом порядке; различается только поведение при повторных запросах с тем же ключом.
+const (
+	MatchKey   = "key"   // повторные запросы получают записи по очереди, последняя повторяется
+	MatchOrder = "order" // повторные запросы получают записи строго по очереди, лишний запрос - ошибка
+)
+
+// DefaultDir каталог файлов обменов по умолчанию
+const DefaultDir = "testdata/fixtures"
+
+// savedHeaders заголовки ответа, которые влияют на обработку и сохраняются в файл
+var savedHeaders = []string{"Content-Type", "Retry-After", "Date"}
+
+// Exchange записанный HTTP обмен, одна строка JSON в файле
+type Exchange struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	Status     int         `json:"status"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 string      `json:"body_base64,omitempty"` // тело не в UTF-8, например XML ЦБ РФ в windows-1251
+	RecordedAt int64       `json:"recorded_at"`
+}
+
+// Transport записывает или воспроизводит HTTP обмены; для каждого хоста ведется свой файл <Dir>/<host>.jsonl
+type Transport struct {
+	Mode  string
+	Match string
+	Dir   string
+	Next  http.RoundTripper // используется только при записи
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c := cassetteFor(filepath.Join(t.Dir, fileName(req.URL.Host)))
+	if t.Mode == ModeReplay {
+		return c.replay(req, t.Match)
+	}
+
+	resp, err := t.Next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("reading response for recording: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	exchange := Exchange{
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		Status:     resp.StatusCode,
+		Header:     http.Header{},
+		RecordedAt: time.Now().Unix(),
+	}
+	for _, name := range savedHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			exchange.Header.Set(name, value)
+		}
+	}
+	if utf8.Valid(body) {
+		exchange.Body = string(body)
+	} else {
+		exchange.BodyBase64 = base64.StdEncoding.EncodeToString(body)
+	}
+	if err := c.append(exchange); err != nil {
+		return nil, fmt.Errorf("recording exchange: %w", err)
+	}
+	return resp, nil
+}
+
+// cassette файл обменов одного хоста, общий для всех клиентов процесса
+type cassette struct {
+	mu        sync.Mutex
+	path      string
+	loaded    bool
+	loadErr   error
+	exchanges []Exchange
+	byKey     map[string][]int // ключ запроса -> номера обменов
+	keyNext   map[string]int   // ключ запроса -> следующий обмен
+	file      *os.File
+}
+
+var (
+	registryMu sync.Mutex
+	cassettes  = make(map[string]*cassette)
+)
+
+func cassetteFor(path string) *cassette {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	c, ok := cassettes[path]
+	if !ok {
+		c = &cassette{path: path}
+		cassettes[path] = c
+	}
+	return c
+}
+
+// append дописывает обмен в конец файла
+func (c *cassette) append(exchange Exchange) error {
+	// Без экранирования &, < и >, чтобы URL и тела в файлах читались и правились вручную
+	var line bytes.Buffer
+	encoder := json.NewEncoder(&line)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(exchange); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.file == nil {
+		if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+			return err
+		}
+		file, err := os.OpenFile(c.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		c.file = file
+	}
+	_, err := c.file.Write(line.Bytes())
+	return err
+}
+
+// replay возвращает записанный ответ на запрос
+func (c *cassette) replay(req *http.Request, match string) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+
+	key := requestKey(req.Method, req.URL.String())
+	indexes := c.byKey[key]
+	if len(indexes) == 0 {
+		return nil, fmt.Errorf("no recorded exchange in %s for %s", c.path, key)
+	}
+	i := c.keyNext[key]
+	if i >= len(indexes) {
+		if match == MatchOrder {
+			return nil, fmt.Errorf("no more recorded exchanges in %s for %s: %d recorded", c.path, key, len(indexes))
+		}
+		i = len(indexes) - 1
+	}
+	c.keyNext[key]++
+	exchange := c.exchanges[indexes[i]]
+
+	body := []byte(exchange.Body)
+	if exchange.BodyBase64 != "" {
+		decoded, err := base64.StdEncoding.DecodeString(exchange.BodyBase64)
+		if err != nil {
+			return nil, fmt.Errorf("decoding recorded body for %s: %w", key, err)
+		}
+		body = decoded
+	}
+	header := exchange.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", exchange.Status, http.StatusText(exchange.Status)),
+		StatusCode:    exchange.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// load читает файл обменов при первом воспроизведении
+func (c *cassette) load() error {
+	if c.loaded {
+		return c.loadErr
+	}
+	c.loaded = true
+	c.byKey = make(map[string][]int)
+	c.keyNext = make(map[string]int)
+
+	file, err := os.Open(c.path)
+	if err != nil {
+		c.loadErr = fmt.Errorf("opening fixtures: %w", err)
+		return c.loadErr
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var exchange Exchange
+		if err := json.Unmarshal(scanner.Bytes(), &exchange); err != nil {
+			c.loadErr = fmt.Errorf("%s:%d: %w", c.path, line, err)
+			return c.loadErr
+		}
+		key := requestKey(exchange.Method, exchange.URL)
+		c.byKey[key] = append(c.byKey[key], len(c.exchanges))
+		c.exchanges = append(c.exchanges, exchange)
+	}
+	if err := scanner.Err(); err != nil {
+		c.loadErr = fmt.Errorf("reading fixtures: %w", err)
+	}
+	return c.loadErr
+}
+
+// requestKey ключ запроса: метод и URL с параметрами в отсортированном порядке
+func requestKey(method, rawURL string) string {
+	req, err := http.NewRequest(method, rawURL, nil)
+	if err != nil {
+		return method + " " + rawURL
+	}
+	u := req.URL
+	u.RawQuery = u.Query().Encode()
+	return method + " " + u.String()
+}
+
+// fileName имя файла обменов для хоста
+func fileName(host string) string {
+	return strings.NewReplacer(":", "_", "/", "_").Replace(host) + ".jsonl"
+}
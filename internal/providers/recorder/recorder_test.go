@@ -0,0 +1,178 @@
+package recorder
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func get(t *testing.T, client *http.Client, rawURL string) (string, error) {
+	t.Helper()
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body), nil
+}
+
+// record записывает ответы стенда на запросы paths и возвращает адрес стенда и каталог файлов
+func record(t *testing.T, paths ...string) (string, string) {
+	t.Helper()
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintf(w, "%s#%d", r.URL.RequestURI(), calls.Add(1))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	client := &http.Client{Transport: &Transport{Mode: ModeRecord, Dir: dir, Next: http.DefaultTransport}}
+	for _, path := range paths {
+		if _, err := get(t, client, server.URL+path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return server.URL, dir
+}
+
+func TestReplay(t *testing.T) {
+	tests := []struct {
+		name     string
+		match    string
+		recorded []string
+		requests []string
+		want     []string // пустая строка - ожидается ошибка
+	}{
+		{
+			name:     "key ignores request order",
+			match:    MatchKey,
+			recorded: []string{"/a", "/b"},
+			requests: []string{"/b", "/a"},
+			want:     []string{"/b#2", "/a#1"},
+		},
+		{
+			name:     "key normalizes query order",
+			match:    MatchKey,
+			recorded: []string{"/p?x=1&y=2"},
+			requests: []string{"/p?y=2&x=1"},
+			want:     []string{"/p?x=1&y=2#1"},
+		},
+		{
+			name:     "key repeats the last exchange",
+			match:    MatchKey,
+			recorded: []string{"/a", "/a"},
+			requests: []string{"/a", "/a", "/a"},
+			want:     []string{"/a#1", "/a#2", "/a#2"},
+		},
+		{
+			name:     "order ignores request order across keys",
+			match:    MatchOrder,
+			recorded: []string{"/a", "/b", "/a"},
+			requests: []string{"/b", "/a", "/a"},
+			want:     []string{"/b#2", "/a#1", "/a#3"},
+		},
+		{
+			name:     "order fails after the last exchange",
+			match:    MatchOrder,
+			recorded: []string{"/a"},
+			requests: []string{"/a", "/a"},
+			want:     []string{"/a#1", ""},
+		},
+		{
+			name:     "unknown request",
+			match:    MatchKey,
+			recorded: []string{"/a"},
+			requests: []string{"/c"},
+			want:     []string{""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, dir := record(t, tt.recorded...)
+			client := &http.Client{Transport: &Transport{Mode: ModeReplay, Match: tt.match, Dir: dir}}
+			for i, path := range tt.requests {
+				body, err := get(t, client, base+path)
+				if tt.want[i] == "" {
+					if err == nil {
+						t.Fatalf("request %s: expected error, got %q", path, body)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("request %s: %v", path, err)
+				}
+				if body != tt.want[i] {
+					t.Fatalf("request %s = %q, want %q", path, body, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// Части одного опроса уходят параллельно и приходят к транспорту в произвольном порядке
+func TestReplayConcurrentRequests(t *testing.T) {
+	paths := make([]string, 20)
+	for i := range paths {
+		paths[i] = fmt.Sprintf("/simple/price?ids=coin-%d", i)
+	}
+	for _, match := range []string{MatchKey, MatchOrder} {
+		t.Run(match, func(t *testing.T) {
+			// Состояние файла обменов общее для процесса, поэтому у каждого подтеста свои файлы
+			base, dir := record(t, paths...)
+			client := &http.Client{Transport: &Transport{Mode: ModeReplay, Match: match, Dir: dir}}
+			var wg sync.WaitGroup
+			errs := make(chan error, len(paths))
+			for i := len(paths) - 1; i >= 0; i-- {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					body, err := get(t, client, base+paths[i])
+					if err == nil && !strings.HasPrefix(body, paths[i]+"#") {
+						err = fmt.Errorf("request %s got %q", paths[i], body)
+					}
+					errs <- err
+				}(i)
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestRecordNonUTF8Body(t *testing.T) {
+	body := []byte{0xc4, 0xee, 0xeb, 0xeb, 0xe0, 0xf0} // "Доллар" в windows-1251
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	recordClient := &http.Client{Transport: &Transport{Mode: ModeRecord, Dir: dir, Next: http.DefaultTransport}}
+	if _, err := get(t, recordClient, server.URL+"/daily"); err != nil {
+		t.Fatal(err)
+	}
+
+	replayClient := &http.Client{Transport: &Transport{Mode: ModeReplay, Dir: dir}}
+	got, err := get(t, replayClient, server.URL+"/daily")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(body) {
+		t.Fatalf("replayed body %x, want %x", got, body)
+	}
+}
@@ -0,0 +1,184 @@
+package synthetic
+
+import (
+	"CryptoPriceCollection/internal/types"
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Name название поставщика в конфигурации
+const Name = "synthetic"
+
+// Модели движения цены
+const (
+	ModelGBM        = "gbm"         // геометрическое броуновское движение
+	ModelRandomWalk = "random_walk" // арифметическое случайное блуждание
+)
+
+// Значения по умолчанию
+const (
+	defaultVolatility   = 0.8 // годовая волатильность, близкая к биткоину
+	defaultStartPrice   = 100
+	defaultTickInterval = time.Second
+	minTickInterval     = time.Second // время котировок хранится в секундах, более частые тики совпали бы по времени
+	minPrice            = 1e-9        // случайное блуждание не уходит в ноль и ниже
+)
+
+// year длительность года для пересчета годовых волатильности и дрейфа в шаг
+const year = 365 * 24 * time.Hour
+
+// Settings настройки генератора
+type Settings struct {
+	Model        string
+	Seed         int64         // 0 - случайное зерно
+	Volatility   float64       // годовая волатильность, доля
+	Drift        float64       // годовой дрейф, доля
+	StartPrice   float64       // начальная цена каждой монеты
+	TickInterval time.Duration // шаг модели и период тиков потока, не меньше секунды
+	ExtraCoins   int           // дополнительные монеты synthetic-1..N вне списка наблюдения
+}
+
+// Provider генерирует цены без обращения к внешним API. Каждый вызов FetchPrices и каждый тик потока
+// сдвигает цену монеты на один шаг модели, поэтому при заданном зерне последовательность цен воспроизводима.
+type Provider struct {
+	mu     sync.Mutex
+	rng    *rand.Rand
+	prices map[string]float64
+
+	model      string
+	drift      float64 // за шаг
+	volatility float64 // за шаг
+	startPrice float64
+	tick       time.Duration
+	extraCoins []string
+}
+
+func New(settings Settings) (*Provider, error) {
+	model := settings.Model
+	if model == "" {
+		model = ModelGBM
+	}
+	if model != ModelGBM && model != ModelRandomWalk {
+		return nil, fmt.Errorf("unknown synthetic model %q", model)
+	}
+	seed := settings.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	volatility := settings.Volatility
+	if volatility <= 0 {
+		volatility = defaultVolatility
+	}
+	startPrice := settings.StartPrice
+	if startPrice <= 0 {
+		startPrice = defaultStartPrice
+	}
+	tick := settings.TickInterval
+	if tick <= 0 {
+		tick = defaultTickInterval
+	}
+	if tick < minTickInterval {
+		log.Printf("Synthetic tick interval %s is below %s, using %s: quote timestamps have second resolution", tick, minTickInterval, minTickInterval)
+		tick = minTickInterval
+	}
+	extraCoins := make([]string, 0, settings.ExtraCoins)
+	for i := 1; i <= settings.ExtraCoins; i++ {
+		extraCoins = append(extraCoins, fmt.Sprintf("%s-%d", Name, i))
+	}
+
+	dt := float64(tick) / float64(year)
+	return &Provider{
+		rng:        rand.New(rand.NewSource(seed)),
+		prices:     make(map[string]float64),
+		model:      model,
+		drift:      settings.Drift * dt,
+		volatility: volatility * math.Sqrt(dt),
+		startPrice: startPrice,
+		tick:       tick,
+		extraCoins: extraCoins,
+	}, nil
+}
+
+// Name возвращает название поставщика
+func (p *Provider) Name() string {
+	return Name
+}
+
+// FetchPrices возвращает следующий шаг цены для каждой монеты
+func (p *Provider) FetchPrices(ctx context.Context, coins []string) ([]types.Quote, error) {
+	return p.step(coins, time.Now().Unix()), nil
+}
+
+// StreamPrices выдает котировки по монетам из списка наблюдения и дополнительным монетам каждые TickInterval.
+// Запись в out блокирующая: медленный потребитель замедляет поток, что и нужно для нагрузочных тестов.
+func (p *Provider) StreamPrices(ctx context.Context, watchlist <-chan []string, out chan<- types.Quote) error {
+	var coins []string
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case watched := <-watchlist:
+		coins = p.coinsFor(watched)
+	}
+
+	ticker := time.NewTicker(p.tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case watched := <-watchlist:
+			coins = p.coinsFor(watched)
+		case now := <-ticker.C:
+			for _, quote := range p.step(coins, now.Unix()) {
+				select {
+				case out <- quote:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+	}
+}
+
+// coinsFor дополняет список наблюдения дополнительными монетами, не изменяя переданный срез
+func (p *Provider) coinsFor(watched []string) []string {
+	coins := make([]string, 0, len(watched)+len(p.extraCoins))
+	return append(append(coins, watched...), p.extraCoins...)
+}
+
+// step сдвигает цены монет на один шаг модели
+func (p *Provider) step(coins []string, timestamp int64) []types.Quote {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	quotes := make([]types.Quote, 0, len(coins))
+	for _, coin := range coins {
+		price, ok := p.prices[coin]
+		if !ok {
+			price = p.startPrice
+		} else {
+			shock := p.rng.NormFloat64()
+			switch p.model {
+			case ModelGBM:
+				price *= math.Exp(p.drift - p.volatility*p.volatility/2 + p.volatility*shock)
+			case ModelRandomWalk:
+				price = max(price+p.startPrice*(p.drift+p.volatility*shock), minPrice)
+			}
+		}
+		p.prices[coin] = price
+		quotes = append(quotes, types.Quote{
+			Coin:      coin,
+			Quote:     types.DefaultQuote,
+			Price:     price,
+			Timestamp: timestamp,
+			Source:    Name,
+		})
+	}
+	return quotes
+}
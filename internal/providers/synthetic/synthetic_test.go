@@ -0,0 +1,54 @@
+package synthetic
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewTickInterval(t *testing.T) {
+	tests := []struct {
+		name string
+		tick time.Duration
+		want time.Duration
+	}{
+		{name: "default", tick: 0, want: time.Second},
+		{name: "below a second is clamped", tick: 100 * time.Millisecond, want: time.Second},
+		{name: "one second", tick: time.Second, want: time.Second},
+		{name: "longer", tick: 5 * time.Second, want: 5 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := New(Settings{Seed: 1, TickInterval: tt.tick})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if provider.tick != tt.want {
+				t.Fatalf("tick = %s, want %s", provider.tick, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchPricesIsReproducible(t *testing.T) {
+	prices := func() []float64 {
+		provider, err := New(Settings{Seed: 42, TickInterval: time.Second})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var result []float64
+		for i := 0; i < 5; i++ {
+			quotes, _ := provider.FetchPrices(context.Background(), []string{"bitcoin"})
+			result = append(result, quotes[0].Price)
+		}
+		return result
+	}
+	first, second := prices(), prices()
+	if !reflect.DeepEqual(first, second) {
+		t.Fatalf("same seed produced %v and %v", first, second)
+	}
+	if first[0] != defaultStartPrice || first[1] == first[0] {
+		t.Fatalf("prices %v must start at %v and move", first, defaultStartPrice)
+	}
+}
@@ -0,0 +1,18 @@
+package backfill
+
+import (
+	"CryptoPriceCollection/internal/repositories/backfill/postgresql"
+	"CryptoPriceCollection/internal/system/database"
+)
+
+type Backfill struct {
+	Postgres postgresql.BackfillRepository
+}
+
+func New(
+	db *database.DataBase,
+) *Backfill {
+	return &Backfill{
+		Postgres: postgresql.New(db),
+	}
+}
@@ -0,0 +1,87 @@
+package postgresql
+
+import (
+	"CryptoPriceCollection/internal/system/database"
+	"CryptoPriceCollection/internal/types"
+	"context"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"time"
+)
+
+type BackfillRepository interface {
+	CreateJob(ctx context.Context, coin, quote string, from, to int64) (*types.BackfillJob, error) // Создание задания загрузки истории
+	GetJob(ctx context.Context, id int64) (*types.BackfillJob, error)                              // Получение задания по идентификатору
+	GetUnfinishedJobs(ctx context.Context) ([]types.BackfillJob, error)                            // Получение незавершенных заданий в порядке создания
+	UpdateProgress(ctx context.Context, id, cursor, stored int64) error                            // Сохранение продвижения задания
+	SetStatus(ctx context.Context, id int64, status, lastError string) error                       // Смена статуса задания
+}
+
+type backfillRepository struct {
+	db *database.DataBase
+}
+
+func New(db *database.DataBase) BackfillRepository {
+	return &backfillRepository{
+		db: db,
+	}
+}
+
+const jobColumns = "id, coin, quote, from_ts, to_ts, cursor_ts, status, stored, last_error, created_at, updated_at"
+
+// CreateJob создание задания загрузки истории, загрузка начинается с начала интервала
+func (r *backfillRepository) CreateJob(ctx context.Context, coin, quote string, from, to int64) (*types.BackfillJob, error) {
+	now := time.Now().Unix()
+	query := `INSERT INTO backfill_jobs (coin, quote, from_ts, to_ts, cursor_ts, status, created_at, updated_at)
+			  VALUES ($1, $2, $3, $4, $3, $5, $6, $6)
+			  RETURNING ` + jobColumns
+	rows, err := r.db.Psql.Query(ctx, query, coin, quote, from, to, types.BackfillPending, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	job := &types.BackfillJob{}
+	if err := pgxscan.ScanOne(job, rows); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// GetJob получение задания по идентификатору
+func (r *backfillRepository) GetJob(ctx context.Context, id int64) (*types.BackfillJob, error) {
+	rows, err := r.db.Psql.Query(ctx, "SELECT "+jobColumns+" FROM backfill_jobs WHERE id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	job := &types.BackfillJob{}
+	if err := pgxscan.ScanOne(job, rows); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// GetUnfinishedJobs получение ожидающих и прерванных заданий в порядке создания
+func (r *backfillRepository) GetUnfinishedJobs(ctx context.Context) ([]types.BackfillJob, error) {
+	query := "SELECT " + jobColumns + " FROM backfill_jobs WHERE status IN ($1, $2) ORDER BY id"
+	var jobs []types.BackfillJob
+	if err := pgxscan.Select(ctx, r.db.Psql, &jobs, query, types.BackfillPending, types.BackfillRunning); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// UpdateProgress сохранение продвижения задания после очередной страницы
+func (r *backfillRepository) UpdateProgress(ctx context.Context, id, cursor, stored int64) error {
+	query := "UPDATE backfill_jobs SET cursor_ts = $2, stored = $3, updated_at = $4 WHERE id = $1"
+	_, err := r.db.Psql.Exec(ctx, query, id, cursor, stored, time.Now().Unix())
+	return err
+}
+
+// SetStatus смена статуса задания
+func (r *backfillRepository) SetStatus(ctx context.Context, id int64, status, lastError string) error {
+	query := "UPDATE backfill_jobs SET status = $2, last_error = $3, updated_at = $4 WHERE id = $1"
+	_, err := r.db.Psql.Exec(ctx, query, id, status, lastError, time.Now().Unix())
+	return err
+}
@@ -0,0 +1,18 @@
+package coins
+
+import (
+	"CryptoPriceCollection/internal/repositories/coins/postgresql"
+	"CryptoPriceCollection/internal/system/database"
+)
+
+type Coins struct {
+	Postgres postgresql.CoinsRepository
+}
+
+func New(
+	db *database.DataBase,
+) *Coins {
+	return &Coins{
+		Postgres: postgresql.New(db),
+	}
+}
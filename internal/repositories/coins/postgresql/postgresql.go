@@ -0,0 +1,107 @@
+package postgresql
+
+import (
+	"CryptoPriceCollection/internal/system/database"
+	"CryptoPriceCollection/internal/types"
+	"context"
+	"fmt"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+type CoinsRepository interface {
+	ReplaceCoins(ctx context.Context, coins []types.Coin) error  // Замена справочника монет
+	GetCoins(ctx context.Context) ([]types.Coin, error)          // Получение всего справочника монет
+	GetUpdatedAt(ctx context.Context) (int64, error)             // Время последнего обновления справочника, 0 если он пуст
+	GetAliases(ctx context.Context) ([]types.CoinAlias, error)   // Получение всех псевдонимов
+	AddAlias(ctx context.Context, alias, coin string) error      // Добавление или замена псевдонима
+	RemoveAlias(ctx context.Context, alias string) (bool, error) // Удаление псевдонима, false если его не было
+}
+
+type coinsRepository struct {
+	db *database.DataBase
+}
+
+func New(db *database.DataBase) CoinsRepository {
+	return &coinsRepository{
+		db: db,
+	}
+}
+
+// ReplaceCoins замена справочника монет одной транзакцией: новые и измененные записи обновляются, пропавшие удаляются
+func (r *coinsRepository) ReplaceCoins(ctx context.Context, coins []types.Coin) error {
+	now := time.Now().Unix()
+
+	// Справочник вставляется одним запросом из массивов колонок; повторяющийся id оставляется один раз,
+	// иначе ON CONFLICT не даст обновить строку дважды
+	seen := make(map[string]struct{}, len(coins))
+	ids := make([]string, 0, len(coins))
+	symbols := make([]string, 0, len(coins))
+	names := make([]string, 0, len(coins))
+	for _, coin := range coins {
+		if _, ok := seen[coin.ID]; ok {
+			continue
+		}
+		seen[coin.ID] = struct{}{}
+		ids = append(ids, coin.ID)
+		symbols = append(symbols, coin.Symbol)
+		names = append(names, coin.Name)
+	}
+
+	return r.db.Psql.Transact(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `INSERT INTO coins (id, symbol, name, updated_at)
+				SELECT id, symbol, name, $4::bigint FROM unnest($1::varchar[], $2::varchar[], $3::text[]) AS c(id, symbol, name)
+				ON CONFLICT (id) DO UPDATE SET symbol = EXCLUDED.symbol, name = EXCLUDED.name, updated_at = EXCLUDED.updated_at`,
+			ids, symbols, names, now)
+		if err != nil {
+			return fmt.Errorf("insert coins: %w", err)
+		}
+		_, err = tx.Exec(ctx, "DELETE FROM coins WHERE updated_at < $1", now)
+		return err
+	})
+}
+
+// GetCoins получение всего справочника монет
+func (r *coinsRepository) GetCoins(ctx context.Context) ([]types.Coin, error) {
+	var coins []types.Coin
+	if err := pgxscan.Select(ctx, r.db.Psql, &coins, "SELECT id, symbol, name FROM coins"); err != nil {
+		return nil, err
+	}
+	return coins, nil
+}
+
+// GetUpdatedAt время последнего обновления справочника, 0 если он пуст
+func (r *coinsRepository) GetUpdatedAt(ctx context.Context) (int64, error) {
+	var updatedAt int64
+	if err := r.db.Psql.QueryRow(ctx, "SELECT COALESCE(MAX(updated_at), 0) FROM coins").Scan(&updatedAt); err != nil {
+		return 0, err
+	}
+	return updatedAt, nil
+}
+
+// GetAliases получение всех псевдонимов
+func (r *coinsRepository) GetAliases(ctx context.Context) ([]types.CoinAlias, error) {
+	var aliases []types.CoinAlias
+	if err := pgxscan.Select(ctx, r.db.Psql, &aliases, "SELECT alias, coin FROM coin_aliases ORDER BY alias"); err != nil {
+		return nil, err
+	}
+	return aliases, nil
+}
+
+// AddAlias добавление или замена псевдонима
+func (r *coinsRepository) AddAlias(ctx context.Context, alias, coin string) error {
+	query := `INSERT INTO coin_aliases (alias, coin, created_at) VALUES ($1, $2, $3)
+			  ON CONFLICT (alias) DO UPDATE SET coin = EXCLUDED.coin, created_at = EXCLUDED.created_at`
+	_, err := r.db.Psql.Exec(ctx, query, alias, coin, time.Now().Unix())
+	return err
+}
+
+// RemoveAlias удаление псевдонима, false если его не было
+func (r *coinsRepository) RemoveAlias(ctx context.Context, alias string) (bool, error) {
+	tag, err := r.db.Psql.Exec(ctx, "DELETE FROM coin_aliases WHERE alias = $1", alias)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
@@ -4,18 +4,25 @@ import (
 	"CryptoPriceCollection/internal/system/database"
 	"CryptoPriceCollection/internal/types"
 	"context"
+	"fmt"
 	"github.com/georgysavva/scany/v2/pgxscan"
 	"github.com/jackc/pgx/v5"
 	"log"
 )
 
 type CryptoRepository interface {
-	AddCurrency(ctx context.Context, coin string) error                                       // Добавление валюты в список наблюдаемых валют
-	RemoveCurrency(ctx context.Context, coin string) error                                    // Удаление валюты из списка наблюдаемых валю
-	GetPrice(ctx context.Context, coin string, timestamp int64) (*types.CurrencyPrice, error) // Получение цены валюты с указанием времени (если такой нет, то возьмется ближайшее время к заданному)
-	GetLatestPrice(ctx context.Context, coin string) (*types.CurrencyPrice, error)            // Получение последней цены валюты
-	GetWatchedCurrencies(ctx context.Context) ([]string, error)                               // Получение всех валют, которые наблюдаются
-	StoreBatch(ctx context.Context, batch []types.CurrencyPrice) error                        // Пакетная вставка цен
+	AddCurrency(ctx context.Context, coin string, interval *int64, tier string) error                             // Добавление валюты в список наблюдаемых валют
+	RemoveCurrency(ctx context.Context, coin string) error                                                        // Удаление валюты из списка наблюдаемых валю
+	GetPrice(ctx context.Context, coin, quote, source string, timestamp int64) (*types.CurrencyPrice, error)      // Получение цены валюты с указанием времени (если такой нет, то возьмется ближайшее время к заданному)
+	GetLatestPrice(ctx context.Context, coin, quote, source string) (*types.CurrencyPrice, error)                 // Получение последней цены валюты
+	GetWatchedCurrencies(ctx context.Context) ([]string, error)                                                   // Получение всех валют, которые наблюдаются
+	GetWatchedSchedules(ctx context.Context) ([]types.WatchedCurrency, error)                                     // Получение наблюдаемых валют с расписанием опроса
+	GetTimestamps(ctx context.Context, coin, quote, source string, from, to int64) (map[int64]struct{}, error)    // Получение времени уже сохраненных цен источника за интервал
+	GetPriceRanges(ctx context.Context, coins []string, since int64) ([]types.PriceRange, error)                  // Диапазон цен монет по валютам котировки начиная с since
+	StoreBatch(ctx context.Context, batch []types.CurrencyPrice) error                                            // Пакетная вставка цен
+	StoreCandles(ctx context.Context, candles []types.Candle) error                                               // Вставка или обновление свечей
+	GetCandles(ctx context.Context, coin, quote, interval, source string, from, to int64) ([]types.Candle, error) // Получение свечей за интервал
+	GetLatestCandleTime(ctx context.Context, coin, quote, interval, source string) (int64, error)                 // Время открытия последней сохраненной свечи, 0 если свечей нет
 }
 
 type cryptoRepository struct {
@@ -28,15 +35,17 @@ func New(db *database.DataBase) CryptoRepository {
 	}
 }
 
-// AddCurrency добавление валюты в список наблюдаемых валют
-func (r *cryptoRepository) AddCurrency(ctx context.Context, coin string) error {
-	query := "INSERT INTO watched_currencies (coin) VALUES ($1) ON CONFLICT DO NOTHING"
-	tag, err := r.db.Psql.Exec(ctx, query, coin)
-	if err != nil {
+// AddCurrency добавление валюты в список наблюдаемых валют; у уже наблюдаемой валюты обновляется расписание опроса
+func (r *cryptoRepository) AddCurrency(ctx context.Context, coin string, interval *int64, tier string) error {
+	query := `INSERT INTO watched_currencies (coin, fetch_interval, tier) VALUES ($1, $2, NULLIF($3, ''))
+			  ON CONFLICT (coin) DO UPDATE SET fetch_interval = EXCLUDED.fetch_interval, tier = EXCLUDED.tier
+			  RETURNING xmax = 0`
+	var inserted bool
+	if err := r.db.Psql.QueryRow(ctx, query, coin, interval, tier).Scan(&inserted); err != nil {
 		return err
 	}
-	if tag.RowsAffected() == 0 {
-		log.Printf("The %s currency already exists or has not been added", coin)
+	if !inserted {
+		log.Printf("The %s currency already exists, its fetch schedule has been updated", coin)
 	}
 
 	return nil
@@ -56,14 +65,21 @@ func (r *cryptoRepository) RemoveCurrency(ctx context.Context, coin string) erro
 	return nil
 }
 
+// sourceFilter условие выбора источника по параметру запроса: пустой источник означает итоговую цену
+func sourceFilter(param int) string {
+	return fmt.Sprintf("(($%[1]d = '' AND NOT raw) OR source = $%[1]d)", param)
+}
+
 // GetPrice получение цены валюты с указанием времени (если такой нет, то возьмется ближайшее время к заданному)
-func (r *cryptoRepository) GetPrice(ctx context.Context, coin string, timestamp int64) (*types.CurrencyPrice, error) {
-	query := `SELECT coin, price, timestamp
+func (r *cryptoRepository) GetPrice(ctx context.Context, coin, quote, source string, timestamp int64) (*types.CurrencyPrice, error) {
+	query := `SELECT coin, quote, price, timestamp, source, raw, market_cap, volume_24h, change_24h, confidence
 			  FROM currency_prices
 			  WHERE coin = $1
+			    AND quote = $4
+			    AND ` + sourceFilter(3) + `
 			  ORDER BY ABS(timestamp - $2)
 			  LIMIT 1`
-	rows, err := r.db.Psql.Query(ctx, query, coin, timestamp)
+	rows, err := r.db.Psql.Query(ctx, query, coin, timestamp, source, quote)
 	if err != nil {
 		return nil, err
 	}
@@ -79,13 +95,15 @@ func (r *cryptoRepository) GetPrice(ctx context.Context, coin string, timestamp
 }
 
 // GetLatestPrice получение последней цены валюты
-func (r *cryptoRepository) GetLatestPrice(ctx context.Context, coin string) (*types.CurrencyPrice, error) {
-	query := `SELECT coin, price, timestamp
+func (r *cryptoRepository) GetLatestPrice(ctx context.Context, coin, quote, source string) (*types.CurrencyPrice, error) {
+	query := `SELECT coin, quote, price, timestamp, source, raw, market_cap, volume_24h, change_24h, confidence
 			  FROM currency_prices
 			  WHERE coin = $1
-			  ORDER BY timestamp DESC 
+			    AND quote = $3
+			    AND ` + sourceFilter(2) + `
+			  ORDER BY timestamp DESC
 			  LIMIT 1`
-	rows, err := r.db.Psql.Query(ctx, query, coin)
+	rows, err := r.db.Psql.Query(ctx, query, coin, source, quote)
 	if err != nil {
 		return nil, err
 	}
@@ -121,16 +139,269 @@ func (r *cryptoRepository) GetWatchedCurrencies(ctx context.Context) ([]string,
 	return coins, nil
 }
 
-// StoreBatch вставка пакеты с ценами
+// GetWatchedSchedules получение наблюдаемых валют с расписанием опроса
+func (r *cryptoRepository) GetWatchedSchedules(ctx context.Context) ([]types.WatchedCurrency, error) {
+	query := "SELECT coin, fetch_interval, COALESCE(tier, '') AS tier FROM watched_currencies"
+	var watched []types.WatchedCurrency
+	if err := pgxscan.Select(ctx, r.db.Psql, &watched, query); err != nil {
+		return nil, err
+	}
+	return watched, nil
+}
+
+// GetTimestamps получение времени уже сохраненных цен источника за интервал
+func (r *cryptoRepository) GetTimestamps(ctx context.Context, coin, quote, source string, from, to int64) (map[int64]struct{}, error) {
+	query := `SELECT timestamp
+			  FROM currency_prices
+			  WHERE coin = $1 AND quote = $2 AND source = $3 AND timestamp BETWEEN $4 AND $5`
+	rows, err := r.db.Psql.Query(ctx, query, coin, quote, source, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	timestamps := make(map[int64]struct{})
+	for rows.Next() {
+		var timestamp int64
+		if err := rows.Scan(&timestamp); err != nil {
+			return nil, err
+		}
+		timestamps[timestamp] = struct{}{}
+	}
+	return timestamps, rows.Err()
+}
+
+// GetPriceRanges диапазон итоговых (не исходных) цен монет по валютам котировки начиная с since
+func (r *cryptoRepository) GetPriceRanges(ctx context.Context, coins []string, since int64) ([]types.PriceRange, error) {
+	query := `SELECT coin, quote, MIN(price) AS low, MAX(price) AS high, COUNT(*) AS count
+			  FROM currency_prices
+			  WHERE coin = ANY($1) AND timestamp >= $2 AND NOT raw
+			  GROUP BY coin, quote`
+	var ranges []types.PriceRange
+	if err := pgxscan.Select(ctx, r.db.Psql, &ranges, query, coins, since); err != nil {
+		return nil, err
+	}
+	return ranges, nil
+}
+
+// priceColumns колонки currency_prices, заполняемые пакетной вставкой
+var priceColumns = []string{"coin", "quote", "price", "timestamp", "source", "raw", "market_cap", "volume_24h", "change_24h", "confidence"}
+
+const priceColumnList = "coin, quote, price, timestamp, source, raw, market_cap, volume_24h, change_24h, confidence"
+
+// StoreBatch вставка пакета с ценами: крупные пакеты (от DB_COPY_THRESHOLD) пишутся через COPY,
+// небольшие - одним INSERT ... SELECT unnest, чтобы не платить за установку COPY на каждый мелкий пакет.
+// Цены с уже сохраненным ключом (coin, quote, source, timestamp) обрабатываются по DB_CONFLICT_POLICY,
+// поэтому повторная запись того же пакета безопасна.
 func (r *cryptoRepository) StoreBatch(ctx context.Context, batch []types.CurrencyPrice) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	batch = dedupeBatch(batch, r.db.ConflictPolicy)
+	if len(batch) >= r.db.CopyThreshold {
+		return r.copyBatch(ctx, batch)
+	}
+	return r.insertBatch(ctx, batch)
+}
+
+// copyBatch вставка пакета через протокол COPY во временную таблицу, из которой цены переносятся с ON CONFLICT
+func (r *cryptoRepository) copyBatch(ctx context.Context, batch []types.CurrencyPrice) error {
 	return r.db.Psql.Transact(ctx, func(ctx context.Context, tx pgx.Tx) error {
-		for _, price := range batch {
-			_, err := tx.Exec(ctx, "INSERT INTO currency_prices (coin, price, timestamp) VALUES ($1, $2, $3)",
-				price.Coin, price.Price, price.Timestamp)
-			if err != nil {
-				return err
-			}
+		_, err := tx.Exec(ctx, `CREATE TEMP TABLE currency_prices_stage ON COMMIT DROP AS
+			SELECT `+priceColumnList+` FROM currency_prices WITH NO DATA`)
+		if err != nil {
+			return fmt.Errorf("create stage table: %w", err)
+		}
+
+		rows := pgx.CopyFromSlice(len(batch), func(i int) ([]any, error) {
+			price := batch[i]
+			return []any{price.Coin, price.Quote, price.Price, price.Timestamp, price.Source, price.Raw,
+				price.MarketCap, price.Volume24h, price.Change24h, price.Confidence}, nil
+		})
+		if _, err := tx.CopyFrom(ctx, pgx.Identifier{"currency_prices_stage"}, priceColumns, rows); err != nil {
+			return fmt.Errorf("copy prices: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, `INSERT INTO currency_prices (`+priceColumnList+`)
+			SELECT `+priceColumnList+` FROM currency_prices_stage `+conflictClause(r.db.ConflictPolicy))
+		if err != nil {
+			return fmt.Errorf("insert staged prices: %w", err)
 		}
 		return nil
 	})
 }
+
+// insertBatch вставка пакета одним запросом из массивов колонок
+func (r *cryptoRepository) insertBatch(ctx context.Context, batch []types.CurrencyPrice) error {
+	coins := make([]string, len(batch))
+	quotes := make([]string, len(batch))
+	prices := make([]float64, len(batch))
+	timestamps := make([]int64, len(batch))
+	sources := make([]string, len(batch))
+	raw := make([]bool, len(batch))
+	marketCaps := make([]*float64, len(batch))
+	volumes := make([]*float64, len(batch))
+	changes := make([]*float64, len(batch))
+	confidences := make([]*float64, len(batch))
+	for i, price := range batch {
+		coins[i] = price.Coin
+		quotes[i] = price.Quote
+		prices[i] = price.Price
+		timestamps[i] = price.Timestamp
+		sources[i] = price.Source
+		raw[i] = price.Raw
+		marketCaps[i] = price.MarketCap
+		volumes[i] = price.Volume24h
+		changes[i] = price.Change24h
+		confidences[i] = price.Confidence
+	}
+
+	query := `INSERT INTO currency_prices (` + priceColumnList + `)
+			  SELECT * FROM unnest($1::varchar[], $2::varchar[], $3::float8[], $4::bigint[], $5::varchar[], $6::boolean[],
+			                       $7::float8[], $8::float8[], $9::float8[], $10::float8[]) ` + conflictClause(r.db.ConflictPolicy)
+	if _, err := r.db.Psql.Exec(ctx, query, coins, quotes, prices, timestamps, sources, raw, marketCaps, volumes, changes, confidences); err != nil {
+		return fmt.Errorf("insert prices: %w", err)
+	}
+	return nil
+}
+
+// conflictClause ON CONFLICT по естественному ключу цены для политики разрешения конфликтов
+func conflictClause(policy string) string {
+	const update = `ON CONFLICT (coin, quote, source, timestamp) DO UPDATE SET
+			price = EXCLUDED.price, raw = EXCLUDED.raw, market_cap = EXCLUDED.market_cap,
+			volume_24h = EXCLUDED.volume_24h, change_24h = EXCLUDED.change_24h, confidence = EXCLUDED.confidence`
+	switch policy {
+	case database.ConflictOverwrite:
+		return update
+	case database.ConflictHighestConfidence:
+		return update + `
+			WHERE COALESCE(EXCLUDED.confidence, 0) > COALESCE(currency_prices.confidence, 0)`
+	default:
+		return `ON CONFLICT (coin, quote, source, timestamp) DO NOTHING`
+	}
+}
+
+// dedupeBatch оставляет в пакете одну цену на ключ (coin, quote, source, timestamp), выбирая ее по той же политике,
+// что и ON CONFLICT: иначе PostgreSQL не даст обновить одну строку дважды в одном запросе
+func dedupeBatch(batch []types.CurrencyPrice, policy string) []types.CurrencyPrice {
+	type priceKey struct {
+		coin, quote, source string
+		timestamp           int64
+	}
+	index := make(map[priceKey]int, len(batch))
+	deduped := make([]types.CurrencyPrice, 0, len(batch))
+	for _, price := range batch {
+		key := priceKey{coin: price.Coin, quote: price.Quote, source: price.Source, timestamp: price.Timestamp}
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(deduped)
+			deduped = append(deduped, price)
+			continue
+		}
+		switch policy {
+		case database.ConflictOverwrite:
+			deduped[i] = price
+		case database.ConflictHighestConfidence:
+			if confidence(price) > confidence(deduped[i]) {
+				deduped[i] = price
+			}
+		}
+	}
+	return deduped
+}
+
+func confidence(price types.CurrencyPrice) float64 {
+	if price.Confidence == nil {
+		return 0
+	}
+	return *price.Confidence
+}
+
+// StoreCandles вставка свечей одним запросом INSERT ... SELECT unnest; незакрытая свеча, полученная повторно, обновляется
+func (r *cryptoRepository) StoreCandles(ctx context.Context, candles []types.Candle) error {
+	if len(candles) == 0 {
+		return nil
+	}
+	candles = dedupeCandles(candles)
+
+	coins := make([]string, len(candles))
+	quotes := make([]string, len(candles))
+	intervals := make([]string, len(candles))
+	openTimes := make([]int64, len(candles))
+	sources := make([]string, len(candles))
+	opens := make([]float64, len(candles))
+	highs := make([]float64, len(candles))
+	lows := make([]float64, len(candles))
+	closes := make([]float64, len(candles))
+	volumes := make([]*float64, len(candles))
+	for i, candle := range candles {
+		coins[i] = candle.Coin
+		quotes[i] = candle.Quote
+		intervals[i] = candle.Interval
+		openTimes[i] = candle.OpenTime
+		sources[i] = candle.Source
+		opens[i] = candle.Open
+		highs[i] = candle.High
+		lows[i] = candle.Low
+		closes[i] = candle.Close
+		volumes[i] = candle.Volume
+	}
+
+	query := `INSERT INTO currency_candles (coin, quote, interval, open_time, source, open, high, low, close, volume)
+			  SELECT * FROM unnest($1::varchar[], $2::varchar[], $3::varchar[], $4::bigint[], $5::varchar[],
+			                       $6::float8[], $7::float8[], $8::float8[], $9::float8[], $10::float8[])
+			  ON CONFLICT (coin, quote, interval, open_time, source) DO UPDATE
+			  SET open = EXCLUDED.open, high = EXCLUDED.high, low = EXCLUDED.low, close = EXCLUDED.close, volume = EXCLUDED.volume`
+	if _, err := r.db.Psql.Exec(ctx, query, coins, quotes, intervals, openTimes, sources, opens, highs, lows, closes, volumes); err != nil {
+		return fmt.Errorf("insert candles: %w", err)
+	}
+	return nil
+}
+
+// dedupeCandles оставляет последнюю из свечей с одним ключом (coin, quote, interval, open_time, source):
+// PostgreSQL не даст обновить одну строку дважды в одном запросе, а более поздняя свеча содержит более свежие данные
+func dedupeCandles(candles []types.Candle) []types.Candle {
+	type candleKey struct {
+		coin, quote, interval, source string
+		openTime                      int64
+	}
+	index := make(map[candleKey]int, len(candles))
+	deduped := make([]types.Candle, 0, len(candles))
+	for _, candle := range candles {
+		key := candleKey{coin: candle.Coin, quote: candle.Quote, interval: candle.Interval, source: candle.Source, openTime: candle.OpenTime}
+		if i, ok := index[key]; ok {
+			deduped[i] = candle
+			continue
+		}
+		index[key] = len(deduped)
+		deduped = append(deduped, candle)
+	}
+	return deduped
+}
+
+// GetCandles получение свечей за интервал в порядке времени открытия; пустой источник означает любой
+func (r *cryptoRepository) GetCandles(ctx context.Context, coin, quote, interval, source string, from, to int64) ([]types.Candle, error) {
+	query := `SELECT coin, quote, interval, open_time, source, open, high, low, close, volume
+			  FROM currency_candles
+			  WHERE coin = $1 AND quote = $2 AND interval = $3
+			    AND ($4 = '' OR source = $4)
+			    AND open_time BETWEEN $5 AND $6
+			  ORDER BY open_time, source`
+	var candles []types.Candle
+	if err := pgxscan.Select(ctx, r.db.Psql, &candles, query, coin, quote, interval, source, from, to); err != nil {
+		return nil, err
+	}
+	return candles, nil
+}
+
+// GetLatestCandleTime время открытия последней сохраненной свечи, 0 если свечей нет
+func (r *cryptoRepository) GetLatestCandleTime(ctx context.Context, coin, quote, interval, source string) (int64, error) {
+	query := `SELECT COALESCE(MAX(open_time), 0)
+			  FROM currency_candles
+			  WHERE coin = $1 AND quote = $2 AND interval = $3 AND source = $4`
+	var openTime int64
+	if err := r.db.Psql.QueryRow(ctx, query, coin, quote, interval, source).Scan(&openTime); err != nil {
+		return 0, err
+	}
+	return openTime, nil
+}
@@ -0,0 +1,133 @@
+package postgresql
+
+import (
+	"CryptoPriceCollection/internal/config"
+	"CryptoPriceCollection/internal/system/database"
+	"CryptoPriceCollection/internal/types"
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+)
+
+// Бенчмарки пакетной вставки цен идут на БД из переменных окружения POSTGRES_* и DB_*
+// в отдельной схеме с примененными миграциями, которая удаляется после замера:
+//
+//	go test -run '^$' -bench StoreBatch ./internal/repositories/crypto/postgresql/
+
+var benchmarkSizes = []int{10, 100, 1000, 10000}
+
+func BenchmarkStoreBatchUnnest(b *testing.B) {
+	benchmarkStoreBatch(b, math.MaxInt)
+}
+
+func BenchmarkStoreBatchCopy(b *testing.B) {
+	benchmarkStoreBatch(b, 1)
+}
+
+func benchmarkStoreBatch(b *testing.B, copyThreshold int) {
+	db := benchmarkDB(b)
+	db.CopyThreshold = copyThreshold
+	repo := New(db)
+	ctx := context.Background()
+
+	var timestamp int64
+	for _, size := range benchmarkSizes {
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				batch := makeBatch(size, &timestamp)
+				b.StartTimer()
+				if err := repo.StoreBatch(ctx, batch); err != nil {
+					b.Fatalf("StoreBatch: %v", err)
+				}
+			}
+			b.ReportMetric(float64(size*b.N)/b.Elapsed().Seconds(), "rows/s")
+		})
+	}
+}
+
+// benchmarkDB подключение к БД, в котором таблицы приложения лежат в отдельной временной схеме
+func benchmarkDB(b *testing.B) *database.DataBase {
+	b.Helper()
+	cfgPostgres := &types.ConfigPostgres{}
+	cfgConnDB := &types.ConfigConnDB{}
+	if err := config.GetConfigsPath([]any{cfgPostgres, cfgConnDB}); err != nil {
+		b.Fatalf("Get config in enviroment var: %v", err)
+	}
+	if cfgPostgres.PostgresHost == "" {
+		b.Skip("POSTGRES_HOST is not set, skipping database benchmark")
+	}
+
+	admin, err := database.New(cfgPostgres, cfgConnDB)
+	if err != nil {
+		b.Fatalf("Connect to database: %v", err)
+	}
+	ctx := context.Background()
+	schema := fmt.Sprintf("bench_%d", time.Now().UnixNano())
+	if _, err := admin.Psql.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		admin.Psql.Close()
+		b.Fatalf("Create schema: %v", err)
+	}
+	b.Cleanup(func() {
+		if _, err := admin.Psql.Exec(ctx, "DROP SCHEMA "+schema+" CASCADE"); err != nil {
+			b.Errorf("Drop schema %s: %v", schema, err)
+		}
+		admin.Psql.Close()
+	})
+
+	sslMode := cfgPostgres.SSLMode
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+	connStr := fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s&search_path=%s",
+		url.QueryEscape(cfgPostgres.PostgresUser),
+		url.QueryEscape(cfgPostgres.PostgresPassword),
+		cfgPostgres.PostgresHost,
+		cfgPostgres.PostgresPort,
+		cfgPostgres.PostgresDBName,
+		sslMode,
+		schema)
+	m, err := migrate.New("file://../../../../pkg/migrations/", connStr)
+	if err != nil {
+		b.Fatalf("Migration initialization error: %v", err)
+	}
+	err = m.Up()
+	m.Close()
+	if err != nil {
+		b.Fatalf("Migration execution error: %v", err)
+	}
+
+	// Соединения пула получают схему через параметры запуска сервера
+	b.Setenv("PGOPTIONS", "-c search_path="+schema)
+	db, err := database.New(cfgPostgres, cfgConnDB)
+	if err != nil {
+		b.Fatalf("Connect to database: %v", err)
+	}
+	b.Cleanup(db.Psql.Close)
+	return db
+}
+
+// makeBatch пакет синтетических цен с уникальными временами, чтобы строки не пересекались между замерами
+func makeBatch(size int, timestamp *int64) []types.CurrencyPrice {
+	batch := make([]types.CurrencyPrice, size)
+	for i := range batch {
+		*timestamp++
+		marketCap := float64(*timestamp) * 1000
+		batch[i] = types.CurrencyPrice{
+			Coin:      fmt.Sprintf("bench-%d", i%10),
+			Quote:     "usd",
+			Price:     100 + float64(i%100)/10,
+			Timestamp: *timestamp,
+			Source:    "benchmark",
+			MarketCap: &marketCap,
+		}
+	}
+	return batch
+}
@@ -0,0 +1,121 @@
+package postgresql
+
+import (
+	"CryptoPriceCollection/internal/system/database"
+	"CryptoPriceCollection/internal/types"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func confidenceOf(value float64) *float64 {
+	return &value
+}
+
+func TestDedupeBatch(t *testing.T) {
+	// Цены a1..a4 с одним ключом (bitcoin, usd, binance, 100); b - с другим ключом
+	a1 := types.CurrencyPrice{Coin: "bitcoin", Quote: "usd", Source: "binance", Timestamp: 100, Price: 1, Confidence: confidenceOf(0.5)}
+	a2 := types.CurrencyPrice{Coin: "bitcoin", Quote: "usd", Source: "binance", Timestamp: 100, Price: 2, Confidence: confidenceOf(0.9)}
+	a3 := types.CurrencyPrice{Coin: "bitcoin", Quote: "usd", Source: "binance", Timestamp: 100, Price: 3}
+	a4 := types.CurrencyPrice{Coin: "bitcoin", Quote: "usd", Source: "binance", Timestamp: 100, Price: 4, Confidence: confidenceOf(0.7)}
+	b := types.CurrencyPrice{Coin: "bitcoin", Quote: "eur", Source: "binance", Timestamp: 100, Price: 5}
+	noConfidence1 := types.CurrencyPrice{Coin: "ethereum", Quote: "usd", Source: "kraken", Timestamp: 100, Price: 6}
+	noConfidence2 := types.CurrencyPrice{Coin: "ethereum", Quote: "usd", Source: "kraken", Timestamp: 100, Price: 7}
+	zeroConfidence := types.CurrencyPrice{Coin: "ethereum", Quote: "usd", Source: "kraken", Timestamp: 100, Price: 8, Confidence: confidenceOf(0)}
+
+	tests := []struct {
+		name   string
+		policy string
+		batch  []types.CurrencyPrice
+		want   []types.CurrencyPrice
+	}{
+		{name: "no duplicates", policy: database.ConflictIgnore, batch: []types.CurrencyPrice{a1, b}, want: []types.CurrencyPrice{a1, b}},
+		{name: "ignore keeps the first", policy: database.ConflictIgnore, batch: []types.CurrencyPrice{a1, b, a2, a3}, want: []types.CurrencyPrice{a1, b}},
+		{name: "unknown policy keeps the first", policy: "", batch: []types.CurrencyPrice{a1, a2}, want: []types.CurrencyPrice{a1}},
+		{name: "overwrite keeps the last", policy: database.ConflictOverwrite, batch: []types.CurrencyPrice{a1, b, a2, a3}, want: []types.CurrencyPrice{a3, b}},
+		{name: "highest confidence keeps the max", policy: database.ConflictHighestConfidence, batch: []types.CurrencyPrice{a1, b, a2, a4}, want: []types.CurrencyPrice{a2, b}},
+		// Цена без достоверности считается ценой с нулевой достоверностью
+		{name: "highest confidence beats nil", policy: database.ConflictHighestConfidence, batch: []types.CurrencyPrice{a3, a1}, want: []types.CurrencyPrice{a1}},
+		{name: "nil does not replace a confidence", policy: database.ConflictHighestConfidence, batch: []types.CurrencyPrice{a1, a3}, want: []types.CurrencyPrice{a1}},
+		// Как и WHERE с COALESCE(..., 0) >, равная достоверность не заменяет цену
+		{name: "equal confidence keeps the first", policy: database.ConflictHighestConfidence, batch: []types.CurrencyPrice{noConfidence1, noConfidence2, zeroConfidence}, want: []types.CurrencyPrice{noConfidence1}},
+		{name: "empty batch", policy: database.ConflictOverwrite, batch: nil, want: []types.CurrencyPrice{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dedupeBatch(tt.batch, tt.policy); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("dedupeBatch() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConflictClause(t *testing.T) {
+	const (
+		target  = "ON CONFLICT (coin, quote, source, timestamp)"
+		nothing = target + " DO NOTHING"
+		where   = "WHERE COALESCE(EXCLUDED.confidence, 0) > COALESCE(currency_prices.confidence, 0)"
+	)
+	updated := []string{"price = EXCLUDED.price", "raw = EXCLUDED.raw", "market_cap = EXCLUDED.market_cap",
+		"volume_24h = EXCLUDED.volume_24h", "change_24h = EXCLUDED.change_24h", "confidence = EXCLUDED.confidence"}
+
+	tests := []struct {
+		policy     string
+		wantUpdate bool
+		wantWhere  bool
+	}{
+		{policy: database.ConflictIgnore},
+		{policy: ""},
+		{policy: database.ConflictOverwrite, wantUpdate: true},
+		{policy: database.ConflictHighestConfidence, wantUpdate: true, wantWhere: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			clause := strings.Join(strings.Fields(conflictClause(tt.policy)), " ")
+			if !tt.wantUpdate {
+				if clause != nothing {
+					t.Fatalf("conflictClause(%q) = %q, want %q", tt.policy, clause, nothing)
+				}
+				return
+			}
+			if !strings.HasPrefix(clause, target+" DO UPDATE SET ") {
+				t.Fatalf("conflictClause(%q) = %q, want an update of the conflicting row", tt.policy, clause)
+			}
+			for _, column := range updated {
+				if !strings.Contains(clause, column) {
+					t.Errorf("conflictClause(%q) does not set %q", tt.policy, column)
+				}
+			}
+			if got := strings.HasSuffix(clause, where); got != tt.wantWhere {
+				t.Errorf("conflictClause(%q) = %q, confidence condition = %v, want %v", tt.policy, clause, got, tt.wantWhere)
+			}
+		})
+	}
+}
+
+func TestDedupeCandles(t *testing.T) {
+	open := types.Candle{Coin: "bitcoin", Quote: "usd", Interval: "1h", OpenTime: 3600, Source: "binance", Open: 1, High: 2, Low: 1, Close: 2}
+	updated := open
+	updated.High, updated.Close = 3, 3
+	otherInterval := open
+	otherInterval.Interval = "1d"
+	otherSource := open
+	otherSource.Source = "coingecko"
+
+	tests := []struct {
+		name    string
+		candles []types.Candle
+		want    []types.Candle
+	}{
+		{name: "distinct keys", candles: []types.Candle{open, otherInterval, otherSource}, want: []types.Candle{open, otherInterval, otherSource}},
+		// Незакрытая свеча, полученная дважды, сохраняется в последнем виде на месте первой
+		{name: "later candle wins", candles: []types.Candle{open, otherSource, updated}, want: []types.Candle{updated, otherSource}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dedupeCandles(tt.candles); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("dedupeCandles() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package fx
+
+import (
+	"CryptoPriceCollection/internal/repositories/fx/postgresql"
+	"CryptoPriceCollection/internal/system/database"
+)
+
+type FX struct {
+	Postgres postgresql.FXRepository
+}
+
+func New(
+	db *database.DataBase,
+) *FX {
+	return &FX{
+		Postgres: postgresql.New(db),
+	}
+}
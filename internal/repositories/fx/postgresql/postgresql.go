@@ -0,0 +1,94 @@
+package postgresql
+
+import (
+	"CryptoPriceCollection/internal/system/database"
+	"CryptoPriceCollection/internal/types"
+	"context"
+	"fmt"
+	"github.com/georgysavva/scany/v2/pgxscan"
+)
+
+type FXRepository interface {
+	StoreRates(ctx context.Context, rates []types.FXRate) error                                        // Вставка или обновление курсов
+	GetNearestRate(ctx context.Context, base, currency string, timestamp int64) (*types.FXRate, error) // Получение курса, ближайшего к заданному времени
+}
+
+type fxRepository struct {
+	db *database.DataBase
+}
+
+func New(db *database.DataBase) FXRepository {
+	return &fxRepository{
+		db: db,
+	}
+}
+
+// StoreRates вставка курсов одним запросом INSERT ... SELECT unnest; повторно полученный курс за ту же дату обновляется
+func (r *fxRepository) StoreRates(ctx context.Context, rates []types.FXRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+	rates = dedupeRates(rates)
+
+	bases := make([]string, len(rates))
+	currencies := make([]string, len(rates))
+	values := make([]float64, len(rates))
+	timestamps := make([]int64, len(rates))
+	sources := make([]string, len(rates))
+	for i, rate := range rates {
+		bases[i] = rate.Base
+		currencies[i] = rate.Currency
+		values[i] = rate.Rate
+		timestamps[i] = rate.Timestamp
+		sources[i] = rate.Source
+	}
+
+	query := `INSERT INTO fx_rates (base, currency, rate, timestamp, source)
+			  SELECT * FROM unnest($1::varchar[], $2::varchar[], $3::float8[], $4::bigint[], $5::varchar[])
+			  ON CONFLICT (base, currency, source, timestamp) DO UPDATE SET rate = EXCLUDED.rate`
+	if _, err := r.db.Psql.Exec(ctx, query, bases, currencies, values, timestamps, sources); err != nil {
+		return fmt.Errorf("insert fx rates: %w", err)
+	}
+	return nil
+}
+
+// dedupeRates оставляет последний из курсов с одним ключом (base, currency, source, timestamp):
+// PostgreSQL не даст обновить одну строку дважды в одном запросе
+func dedupeRates(rates []types.FXRate) []types.FXRate {
+	type rateKey struct {
+		base, currency, source string
+		timestamp              int64
+	}
+	index := make(map[rateKey]int, len(rates))
+	deduped := make([]types.FXRate, 0, len(rates))
+	for _, rate := range rates {
+		key := rateKey{base: rate.Base, currency: rate.Currency, source: rate.Source, timestamp: rate.Timestamp}
+		if i, ok := index[key]; ok {
+			deduped[i] = rate
+			continue
+		}
+		index[key] = len(deduped)
+		deduped = append(deduped, rate)
+	}
+	return deduped
+}
+
+// GetNearestRate получение курса, ближайшего к заданному времени
+func (r *fxRepository) GetNearestRate(ctx context.Context, base, currency string, timestamp int64) (*types.FXRate, error) {
+	query := `SELECT base, currency, rate, timestamp, source
+			  FROM fx_rates
+			  WHERE base = $1 AND currency = $2
+			  ORDER BY ABS(timestamp - $3), source
+			  LIMIT 1`
+	rows, err := r.db.Psql.Query(ctx, query, base, currency, timestamp)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rate := &types.FXRate{}
+	if err := pgxscan.ScanOne(rate, rows); err != nil {
+		return nil, err
+	}
+	return rate, nil
+}
@@ -0,0 +1,33 @@
+package postgresql
+
+import (
+	"CryptoPriceCollection/internal/types"
+	"reflect"
+	"testing"
+)
+
+func TestDedupeRates(t *testing.T) {
+	eur := types.FXRate{Base: "usd", Currency: "eur", Rate: 0.92, Timestamp: 1700006400, Source: "ecb"}
+	corrected := eur
+	corrected.Rate = 0.93
+	otherSource := eur
+	otherSource.Source = "cbr"
+	nextDay := eur
+	nextDay.Timestamp += 86400
+
+	tests := []struct {
+		name  string
+		rates []types.FXRate
+		want  []types.FXRate
+	}{
+		{name: "distinct keys", rates: []types.FXRate{eur, otherSource, nextDay}, want: []types.FXRate{eur, otherSource, nextDay}},
+		{name: "later rate wins", rates: []types.FXRate{eur, otherSource, corrected}, want: []types.FXRate{corrected, otherSource}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dedupeRates(tt.rates); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("dedupeRates() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,18 +1,27 @@
 package repositories
 
 import (
+	"CryptoPriceCollection/internal/repositories/backfill"
+	"CryptoPriceCollection/internal/repositories/coins"
 	"CryptoPriceCollection/internal/repositories/crypto"
+	"CryptoPriceCollection/internal/repositories/fx"
 	"CryptoPriceCollection/internal/system"
 )
 
 type Repositories struct {
-	Crypto *crypto.Crypto
+	Crypto   *crypto.Crypto
+	Backfill *backfill.Backfill
+	Coins    *coins.Coins
+	FX       *fx.FX
 }
 
 func New(
 	sys *system.Systems,
 ) *Repositories {
 	return &Repositories{
-		Crypto: crypto.New(sys.DB),
+		Crypto:   crypto.New(sys.DB),
+		Backfill: backfill.New(sys.DB),
+		Coins:    coins.New(sys.DB),
+		FX:       fx.New(sys.DB),
 	}
 }
@@ -0,0 +1,216 @@
+package backfill
+
+import (
+	"CryptoPriceCollection/internal/providers"
+	"CryptoPriceCollection/internal/repositories"
+	"CryptoPriceCollection/internal/types"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+type BackfillServiceInterface interface {
+	CreateJob(ctx context.Context, coin, quote string, from int64, to *int64) (*types.BackfillJob, error) // Создание задания загрузки истории
+	GetJob(ctx context.Context, id int64) (*types.BackfillJob, error)                                     // Получение состояния задания
+	ScheduleOnAdd(ctx context.Context, coin string)                                                       // Загрузка истории для только что добавленной валюты
+	Start(ctx context.Context)                                                                            // Фоновое выполнение заданий
+}
+
+// ErrInvalidRange некорректный интервал загрузки истории
+var ErrInvalidRange = errors.New("invalid backfill range")
+
+// Значения по умолчанию
+const (
+	defaultPageDays  = 90 // максимальный интервал, для которого CoinGecko отдает почасовые точки
+	defaultOnAddDays = 30
+	retryInterval    = time.Minute // повторная проверка заданий после ошибки БД
+)
+
+// historyConfidence достоверность исторической точки: почасовые точки CoinGecko ниже живых котировок,
+// поэтому при политике highest_confidence не заменяют их
+const historyConfidence = 0.5
+
+type BackfillService struct {
+	repo      repositories.Repositories
+	history   providers.HistoryProvider
+	quotes    []string
+	page      int64 // в секундах
+	onAdd     bool
+	onAddDays int
+	wake      chan struct{}
+}
+
+func NewBackfillService(repo repositories.Repositories, history providers.HistoryProvider, quotes []string, cfgBackfill *types.ConfigBackfill) *BackfillService {
+	pageDays := cfgBackfill.PageDays
+	if pageDays <= 0 {
+		pageDays = defaultPageDays
+	}
+	onAddDays := cfgBackfill.OnAddDays
+	if onAddDays <= 0 {
+		onAddDays = defaultOnAddDays
+	}
+	return &BackfillService{
+		repo:      repo,
+		history:   history,
+		quotes:    quotes,
+		page:      int64(pageDays) * 24 * 60 * 60,
+		onAdd:     cfgBackfill.OnAdd,
+		onAddDays: onAddDays,
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// CreateJob создает задание загрузки истории за [from, to]; без to история загружается до текущего момента
+func (s *BackfillService) CreateJob(ctx context.Context, coin, quote string, from int64, to *int64) (*types.BackfillJob, error) {
+	if quote == "" {
+		quote = types.DefaultQuote
+	}
+	quote = strings.ToLower(quote)
+
+	now := time.Now().Unix()
+	end := now
+	if to != nil && *to < now {
+		end = *to
+	}
+	if from <= 0 || from >= end {
+		return nil, fmt.Errorf("%w: from=%d to=%d", ErrInvalidRange, from, end)
+	}
+
+	job, err := s.repo.Backfill.Postgres.CreateJob(ctx, coin, quote, from, end)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create backfill job: %w", err)
+	}
+	s.notify()
+	return job, nil
+}
+
+// GetJob возвращает состояние задания
+func (s *BackfillService) GetJob(ctx context.Context, id int64) (*types.BackfillJob, error) {
+	return s.repo.Backfill.Postgres.GetJob(ctx, id)
+}
+
+// ScheduleOnAdd создает задания загрузки истории по всем валютам котировки, если это включено в конфигурации
+func (s *BackfillService) ScheduleOnAdd(ctx context.Context, coin string) {
+	if !s.onAdd {
+		return
+	}
+	from := time.Now().AddDate(0, 0, -s.onAddDays).Unix()
+	for _, quote := range s.quotes {
+		if _, err := s.CreateJob(ctx, coin, quote, from, nil); err != nil {
+			log.Printf("Error scheduling backfill for %s/%s: %v", coin, quote, err)
+		}
+	}
+}
+
+// Start выполняет незавершенные задания по очереди, в том числе прерванные при прошлом запуске
+func (s *BackfillService) Start(ctx context.Context) {
+	for {
+		jobs, err := s.repo.Backfill.Postgres.GetUnfinishedJobs(ctx)
+		if err != nil {
+			log.Printf("Error fetching backfill jobs: %v", err)
+		}
+		for _, job := range jobs {
+			s.runJob(ctx, job)
+			if ctx.Err() != nil {
+				return
+			}
+		}
+
+		var retry <-chan time.Time
+		if err != nil {
+			retry = time.After(retryInterval)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-retry:
+		}
+	}
+}
+
+// runJob загружает историю постранично, сохраняя продвижение после каждой страницы
+func (s *BackfillService) runJob(ctx context.Context, job types.BackfillJob) {
+	if err := s.repo.Backfill.Postgres.SetStatus(ctx, job.ID, types.BackfillRunning, ""); err != nil {
+		log.Printf("Error starting backfill job %d: %v", job.ID, err)
+		return
+	}
+	log.Printf("Backfill job %d started: %s/%s from %d to %d (cursor %d)", job.ID, job.Coin, job.Quote, job.FromTS, job.ToTS, job.CursorTS)
+
+	cursor, stored := job.CursorTS, job.Stored
+	for cursor < job.ToTS {
+		pageEnd := min(cursor+s.page, job.ToTS)
+		count, err := s.storePage(ctx, job.Coin, job.Quote, cursor, pageEnd)
+		if err != nil {
+			if ctx.Err() != nil {
+				// Задание останется в статусе running и продолжится после перезапуска
+				return
+			}
+			log.Printf("Backfill job %d failed: %v", job.ID, err)
+			if err := s.repo.Backfill.Postgres.SetStatus(ctx, job.ID, types.BackfillFailed, err.Error()); err != nil {
+				log.Printf("Error updating backfill job %d: %v", job.ID, err)
+			}
+			return
+		}
+		cursor, stored = pageEnd, stored+int64(count)
+		if err := s.repo.Backfill.Postgres.UpdateProgress(ctx, job.ID, cursor, stored); err != nil {
+			log.Printf("Error saving backfill job %d progress: %v", job.ID, err)
+			return
+		}
+	}
+
+	if err := s.repo.Backfill.Postgres.SetStatus(ctx, job.ID, types.BackfillDone, ""); err != nil {
+		log.Printf("Error finishing backfill job %d: %v", job.ID, err)
+		return
+	}
+	log.Printf("Backfill job %d done: %d points stored", job.ID, stored)
+}
+
+// storePage загружает одну страницу истории и сохраняет точки, которых еще нет в БД
+func (s *BackfillService) storePage(ctx context.Context, coin, quote string, from, to int64) (int, error) {
+	quotes, err := s.history.FetchRange(ctx, coin, quote, from, to)
+	if err != nil {
+		return 0, fmt.Errorf("fetching %s history: %w", s.history.Name(), err)
+	}
+	existing, err := s.repo.Crypto.Postgres.GetTimestamps(ctx, coin, quote, s.history.Name(), from, to)
+	if err != nil {
+		return 0, fmt.Errorf("fetching stored timestamps: %w", err)
+	}
+
+	batch := make([]types.CurrencyPrice, 0, len(quotes))
+	for _, q := range quotes {
+		if _, ok := existing[q.Timestamp]; ok {
+			continue
+		}
+		existing[q.Timestamp] = struct{}{}
+		confidence := historyConfidence
+		batch = append(batch, types.CurrencyPrice{
+			Coin:       q.Coin,
+			Quote:      q.Quote,
+			Price:      q.Price,
+			Timestamp:  q.Timestamp,
+			Source:     q.Source,
+			MarketCap:  q.MarketCap,
+			Volume24h:  q.Volume24h,
+			Confidence: &confidence,
+		})
+	}
+	if len(batch) == 0 {
+		return 0, nil
+	}
+	if err := s.repo.Crypto.Postgres.StoreBatch(ctx, batch); err != nil {
+		return 0, fmt.Errorf("storing history: %w", err)
+	}
+	return len(batch), nil
+}
+
+// notify будит фоновый обработчик заданий
+func (s *BackfillService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
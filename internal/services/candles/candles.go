@@ -0,0 +1,164 @@
+package candles
+
+import (
+	"CryptoPriceCollection/internal/providers"
+	"CryptoPriceCollection/internal/repositories"
+	"CryptoPriceCollection/internal/types"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CandlesServiceInterface interface {
+	GetCandles(ctx context.Context, coin, quote, interval, source string, from int64, to *int64) ([]types.Candle, error) // Получение свечей за интервал
+	Start(ctx context.Context)                                                                                           // Фоновое получение свечей
+}
+
+// ErrInvalidInterval некорректная длительность свечи
+var ErrInvalidInterval = errors.New("invalid candle interval")
+
+const (
+	defaultFetchInterval = time.Minute
+	initialCandles       = 500 // глубина первой загрузки, свечей
+)
+
+type CandlesService struct {
+	repo          repositories.Repositories
+	provider      providers.CandleProvider
+	intervals     []string
+	quotes        []string
+	fetchInterval time.Duration
+}
+
+func NewCandlesService(repo repositories.Repositories, provider providers.CandleProvider, cfgCandles *types.ConfigCandles) *CandlesService {
+	var intervals []string
+	for _, interval := range providers.ParseList(cfgCandles.Intervals) {
+		if _, err := ParseInterval(interval); err != nil {
+			log.Printf("Skipping candle interval %q: %v", interval, err)
+			continue
+		}
+		intervals = append(intervals, interval)
+	}
+	var quotes []string
+	for _, quote := range providers.ParseList(cfgCandles.Quotes) {
+		quotes = append(quotes, strings.ToLower(quote))
+	}
+	if len(quotes) == 0 {
+		quotes = []string{types.DefaultQuote}
+	}
+	fetchInterval := time.Duration(cfgCandles.FetchInterval) * time.Millisecond
+	if fetchInterval <= 0 {
+		fetchInterval = defaultFetchInterval
+	}
+	return &CandlesService{
+		repo:          repo,
+		provider:      provider,
+		intervals:     intervals,
+		quotes:        quotes,
+		fetchInterval: fetchInterval,
+	}
+}
+
+// GetCandles возвращает сохраненные свечи за [from, to]; без to - до текущего момента
+func (s *CandlesService) GetCandles(ctx context.Context, coin, quote, interval, source string, from int64, to *int64) ([]types.Candle, error) {
+	if _, err := ParseInterval(interval); err != nil {
+		return nil, err
+	}
+	if quote == "" {
+		quote = types.DefaultQuote
+	}
+	end := time.Now().Unix()
+	if to != nil {
+		end = *to
+	}
+	return s.repo.Crypto.Postgres.GetCandles(ctx, coin, strings.ToLower(quote), interval, source, from, end)
+}
+
+// Start периодически догружает свечи по всем наблюдаемым монетам; без интервалов в конфигурации ничего не делает
+func (s *CandlesService) Start(ctx context.Context) {
+	if s.provider == nil || len(s.intervals) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.fetchInterval)
+	defer ticker.Stop()
+
+	for {
+		s.fetchAndStoreCandles(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// fetchAndStoreCandles загружает свечи начиная с последней сохраненной, чтобы обновить ее, если она еще не закрыта
+func (s *CandlesService) fetchAndStoreCandles(ctx context.Context) {
+	coins, err := s.repo.Crypto.Postgres.GetWatchedCurrencies(ctx)
+	if err != nil {
+		log.Printf("Error fetching watched currencies: %v", err)
+		return
+	}
+
+	now := time.Now().Unix()
+	for _, coin := range coins {
+		for _, quote := range s.quotes {
+			for _, interval := range s.intervals {
+				if ctx.Err() != nil {
+					return
+				}
+				from, err := s.repo.Crypto.Postgres.GetLatestCandleTime(ctx, coin, quote, interval, s.provider.Name())
+				if err != nil {
+					log.Printf("Error fetching latest %s candle for %s/%s: %v", interval, coin, quote, err)
+					continue
+				}
+				if from == 0 {
+					duration, _ := ParseInterval(interval)
+					from = now - initialCandles*int64(duration.Seconds())
+				}
+
+				candles, err := s.provider.FetchCandles(ctx, coin, quote, interval, from, now)
+				if err != nil {
+					log.Printf("Error fetching %s candles for %s/%s from %s: %v", interval, coin, quote, s.provider.Name(), err)
+					continue
+				}
+				if len(candles) == 0 {
+					continue
+				}
+				if err := s.repo.Crypto.Postgres.StoreCandles(ctx, candles); err != nil {
+					log.Printf("Error storing %s candles for %s/%s: %v", interval, coin, quote, err)
+				}
+			}
+		}
+	}
+}
+
+// ParseInterval разбирает длительность свечи вида 30m, 4h, 1d, 1w
+func ParseInterval(interval string) (time.Duration, error) {
+	if len(interval) < 2 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidInterval, interval)
+	}
+	count, err := strconv.Atoi(interval[:len(interval)-1])
+	if err != nil || count <= 0 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidInterval, interval)
+	}
+	var unit time.Duration
+	switch interval[len(interval)-1] {
+	case 'm':
+		unit = time.Minute
+	case 'h':
+		unit = time.Hour
+	case 'd':
+		unit = 24 * time.Hour
+	case 'w':
+		unit = 7 * 24 * time.Hour
+	default:
+		return 0, fmt.Errorf("%w: %q", ErrInvalidInterval, interval)
+	}
+	return time.Duration(count) * unit, nil
+}
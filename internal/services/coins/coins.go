@@ -0,0 +1,309 @@
+package coins
+
+import (
+	"CryptoPriceCollection/internal/providers"
+	"CryptoPriceCollection/internal/repositories"
+	"CryptoPriceCollection/internal/types"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type CoinsServiceInterface interface {
+	Resolve(ctx context.Context, input string) (string, error) // Приведение тикера, названия или псевдонима к идентификатору монеты
+	Validate(ctx context.Context, coin string) error           // Проверка, что монета есть в справочнике
+	GetAliases(ctx context.Context) ([]types.CoinAlias, error) // Получение всех псевдонимов
+	AddAlias(ctx context.Context, alias, coin string) error    // Добавление или замена псевдонима
+	RemoveAlias(ctx context.Context, alias string) error       // Удаление псевдонима
+	Start(ctx context.Context)                                 // Фоновое обновление справочника
+}
+
+// UnknownCoinError монеты нет в справочнике поставщика
+type UnknownCoinError struct {
+	Coin        string
+	Suggestions []string // Близкие по написанию идентификаторы
+}
+
+func (e *UnknownCoinError) Error() string {
+	return fmt.Sprintf("unknown coin %q", e.Coin)
+}
+
+// AmbiguousCoinError тикер или название подходит к нескольким монетам
+type AmbiguousCoinError struct {
+	Input      string
+	Candidates []types.Coin
+}
+
+func (e *AmbiguousCoinError) Error() string {
+	return fmt.Sprintf("ambiguous coin %q: %d candidates", e.Input, len(e.Candidates))
+}
+
+// ErrAliasNotFound псевдоним не найден
+var ErrAliasNotFound = errors.New("alias not found")
+
+const (
+	defaultRefreshInterval = 24 * time.Hour
+	retryInterval          = 5 * time.Minute // повтор обновления после ошибки
+	maxSuggestions         = 5
+)
+
+type CoinsService struct {
+	repo            repositories.Repositories
+	provider        providers.CoinListProvider
+	refreshInterval time.Duration
+
+	mu       sync.RWMutex
+	coins    map[string]types.Coin   // id -> монета
+	bySymbol map[string][]types.Coin // тикер в нижнем регистре -> монеты
+	byName   map[string][]types.Coin // название в нижнем регистре -> монеты
+	aliases  map[string]string       // псевдоним -> id
+}
+
+func NewCoinsService(repo repositories.Repositories, provider providers.CoinListProvider, cfgCoins *types.ConfigCoins) *CoinsService {
+	refreshInterval := time.Duration(cfgCoins.RefreshInterval) * time.Minute
+	if refreshInterval <= 0 {
+		refreshInterval = defaultRefreshInterval
+	}
+	return &CoinsService{
+		repo:            repo,
+		provider:        provider,
+		refreshInterval: refreshInterval,
+		aliases:         make(map[string]string),
+	}
+}
+
+// Resolve приводит введенное значение к идентификатору CoinGecko: сначала по псевдонимам, затем по id,
+// тикеру и названию. Если подходит несколько монет, возвращается AmbiguousCoinError со списком кандидатов.
+// Неизвестное значение возвращается как есть в нижнем регистре: проверку при добавлении выполняет Validate.
+func (s *CoinsService) Resolve(ctx context.Context, input string) (string, error) {
+	key := strings.ToLower(strings.TrimSpace(input))
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if coin, ok := s.aliases[key]; ok {
+		return coin, nil
+	}
+	if _, ok := s.coins[key]; ok {
+		return key, nil
+	}
+	candidates := s.bySymbol[key]
+	if len(candidates) == 0 {
+		candidates = s.byName[key]
+	}
+	switch len(candidates) {
+	case 0:
+		return key, nil
+	case 1:
+		return candidates[0].ID, nil
+	default:
+		return "", &AmbiguousCoinError{Input: input, Candidates: candidates}
+	}
+}
+
+// GetAliases возвращает все псевдонимы
+func (s *CoinsService) GetAliases(ctx context.Context) ([]types.CoinAlias, error) {
+	return s.repo.Coins.Postgres.GetAliases(ctx)
+}
+
+// AddAlias добавляет псевдоним; монета должна быть в справочнике, если он загружен
+func (s *CoinsService) AddAlias(ctx context.Context, alias, coin string) error {
+	alias = strings.ToLower(strings.TrimSpace(alias))
+	coin = strings.ToLower(strings.TrimSpace(coin))
+	if err := s.Validate(ctx, coin); err != nil {
+		return err
+	}
+	if err := s.repo.Coins.Postgres.AddAlias(ctx, alias, coin); err != nil {
+		return fmt.Errorf("couldn't add alias: %w", err)
+	}
+	s.mu.Lock()
+	s.aliases[alias] = coin
+	s.mu.Unlock()
+	return nil
+}
+
+// RemoveAlias удаляет псевдоним
+func (s *CoinsService) RemoveAlias(ctx context.Context, alias string) error {
+	alias = strings.ToLower(strings.TrimSpace(alias))
+	removed, err := s.repo.Coins.Postgres.RemoveAlias(ctx, alias)
+	if err != nil {
+		return fmt.Errorf("couldn't remove alias: %w", err)
+	}
+	if !removed {
+		return ErrAliasNotFound
+	}
+	s.mu.Lock()
+	delete(s.aliases, alias)
+	s.mu.Unlock()
+	return nil
+}
+
+// Validate проверяет идентификатор монеты по справочнику и предлагает близкие варианты.
+// Пока справочник не загружен, проверка пропускается, чтобы недоступность поставщика не блокировала добавление.
+func (s *CoinsService) Validate(ctx context.Context, coin string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.coins) == 0 {
+		log.Printf("Coin list is not loaded yet, skipping validation of %s", coin)
+		return nil
+	}
+	if _, ok := s.coins[coin]; ok {
+		return nil
+	}
+	return &UnknownCoinError{Coin: coin, Suggestions: s.suggest(coin)}
+}
+
+// Start загружает справочник из БД и обновляет его у поставщика, если он устарел
+func (s *CoinsService) Start(ctx context.Context) {
+	if err := s.load(ctx); err != nil {
+		log.Printf("Error loading coin list: %v", err)
+	}
+
+	for {
+		wait := s.refreshInterval
+		updatedAt, err := s.repo.Coins.Postgres.GetUpdatedAt(ctx)
+		if err != nil {
+			log.Printf("Error fetching coin list update time: %v", err)
+			wait = retryInterval
+		} else if age := time.Since(time.Unix(updatedAt, 0)); age >= s.refreshInterval {
+			if err := s.refresh(ctx); err != nil {
+				log.Printf("Error refreshing coin list: %v", err)
+				wait = retryInterval
+			}
+		} else {
+			wait = s.refreshInterval - age
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// refresh получает справочник у поставщика, сохраняет его в БД и подменяет кэш
+func (s *CoinsService) refresh(ctx context.Context) error {
+	coins, err := s.provider.FetchCoinList(ctx)
+	if err != nil {
+		return fmt.Errorf("fetching %s coin list: %w", s.provider.Name(), err)
+	}
+	if len(coins) == 0 {
+		return fmt.Errorf("%s returned an empty coin list", s.provider.Name())
+	}
+	if err := s.repo.Coins.Postgres.ReplaceCoins(ctx, coins); err != nil {
+		return fmt.Errorf("storing coin list: %w", err)
+	}
+	s.set(coins)
+	log.Printf("Coin list refreshed: %d coins", len(coins))
+	return nil
+}
+
+// load заполняет кэш справочника и псевдонимов из БД
+func (s *CoinsService) load(ctx context.Context) error {
+	aliases, err := s.repo.Coins.Postgres.GetAliases(ctx)
+	if err != nil {
+		return err
+	}
+	byAlias := make(map[string]string, len(aliases))
+	for _, alias := range aliases {
+		byAlias[alias.Alias] = alias.Coin
+	}
+	s.mu.Lock()
+	s.aliases = byAlias
+	s.mu.Unlock()
+
+	coins, err := s.repo.Coins.Postgres.GetCoins(ctx)
+	if err != nil {
+		return err
+	}
+	s.set(coins)
+	return nil
+}
+
+func (s *CoinsService) set(coins []types.Coin) {
+	byID := make(map[string]types.Coin, len(coins))
+	bySymbol := make(map[string][]types.Coin)
+	byName := make(map[string][]types.Coin)
+	for _, coin := range coins {
+		byID[coin.ID] = coin
+		symbol, name := strings.ToLower(coin.Symbol), strings.ToLower(coin.Name)
+		bySymbol[symbol] = append(bySymbol[symbol], coin)
+		byName[name] = append(byName[name], coin)
+	}
+	for _, group := range []map[string][]types.Coin{bySymbol, byName} {
+		for _, candidates := range group {
+			sort.Slice(candidates, func(i, j int) bool { return candidates[i].ID < candidates[j].ID })
+		}
+	}
+	s.mu.Lock()
+	s.coins = byID
+	s.bySymbol = bySymbol
+	s.byName = byName
+	s.mu.Unlock()
+}
+
+// suggest подбирает идентификаторы, близкие к введенному по id, тикеру или названию
+func (s *CoinsService) suggest(input string) []string {
+	input = strings.ToLower(input)
+	maxDistance := max(2, len([]rune(input))/3)
+
+	type candidate struct {
+		id       string
+		distance int
+	}
+	var candidates []candidate
+	for id, coin := range s.coins {
+		distance := levenshtein(input, id)
+		if strings.ToLower(coin.Symbol) == input || strings.ToLower(coin.Name) == input {
+			distance = 0
+		} else {
+			distance = min(distance, levenshtein(input, strings.ToLower(coin.Name)))
+		}
+		if distance <= maxDistance {
+			candidates = append(candidates, candidate{id: id, distance: distance})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].id < candidates[j].id
+	})
+
+	suggestions := make([]string, 0, maxSuggestions)
+	for _, c := range candidates[:min(len(candidates), maxSuggestions)] {
+		suggestions = append(suggestions, c.id)
+	}
+	return suggestions
+}
+
+// levenshtein расстояние редактирования между строками
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
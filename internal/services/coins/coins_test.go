@@ -0,0 +1,158 @@
+package coins
+
+import (
+	"CryptoPriceCollection/internal/repositories"
+	"CryptoPriceCollection/internal/types"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// testCoins справочник монет для тестов; тикер UNI есть у двух монет
+var testCoins = []types.Coin{
+	{ID: "bitcoin", Symbol: "btc", Name: "Bitcoin"},
+	{ID: "bitcoin-cash", Symbol: "bch", Name: "Bitcoin Cash"},
+	{ID: "ethereum", Symbol: "eth", Name: "Ethereum"},
+	{ID: "ethereum-classic", Symbol: "etc", Name: "Ethereum Classic"},
+	{ID: "solana", Symbol: "sol", Name: "Solana"},
+	{ID: "solar", Symbol: "sxp", Name: "Solar"},
+	{ID: "tether", Symbol: "usdt", Name: "Tether"},
+	{ID: "usd-coin", Symbol: "usdc", Name: "USDC"},
+	{ID: "uniswap", Symbol: "uni", Name: "Uniswap"},
+	{ID: "unicorn-token", Symbol: "uni", Name: "Unicorn Token"},
+	{ID: "wrapped-bitcoin", Symbol: "wbtc", Name: "Wrapped Bitcoin"},
+}
+
+func newTestService(coins []types.Coin) *CoinsService {
+	s := NewCoinsService(repositories.Repositories{}, nil, &types.ConfigCoins{})
+	s.set(coins)
+	return s
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "", b: "", want: 0},
+		{a: "abc", b: "", want: 3},
+		{a: "", b: "abc", want: 3},
+		{a: "same", b: "same", want: 0},
+		{a: "kitten", b: "sitting", want: 3},
+		{a: "flaw", b: "lawn", want: 2},
+		{a: "abc", b: "acb", want: 2},
+		{a: "etherium", b: "ethereum", want: 1},
+		// Расстояние считается по символам, а не по байтам
+		{a: "биткоин", b: "биткойн", want: 1},
+	}
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := levenshtein(tt.b, tt.a); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	s := newTestService(testCoins)
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{name: "typo in id", input: "bitcon", want: []string{"bitcoin"}},
+		{name: "typo is case-insensitive", input: "Etherium", want: []string{"ethereum"}},
+		{name: "ticker match comes first", input: "wbtc", want: []string{"wrapped-bitcoin"}},
+		{name: "name match", input: "bitcoin cash", want: []string{"bitcoin-cash"}},
+		{name: "ties are ordered by id", input: "uni", want: []string{"unicorn-token", "uniswap"}},
+		{name: "closer ids come first", input: "solarr", want: []string{"solar", "solana"}},
+		// Для длинного ввода допускается треть символов: bitcoin-c -> bitcoin (2), bitcoin-cash (3)
+		{name: "cutoff grows with input length", input: "bitcoin-c", want: []string{"bitcoin", "bitcoin-cash"}},
+		{name: "nothing within the cutoff", input: "xyz", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.suggest(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("suggest(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuggestLimit(t *testing.T) {
+	var coins []types.Coin
+	for i := 7; i >= 1; i-- {
+		coins = append(coins, types.Coin{ID: fmt.Sprintf("coin-%d", i), Symbol: fmt.Sprintf("c%d", i), Name: fmt.Sprintf("Coin %d", i)})
+	}
+	s := newTestService(coins)
+
+	want := []string{"coin-1", "coin-2", "coin-3", "coin-4", "coin-5"}
+	if got := s.suggest("coin-"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("suggest() = %v, want the first %d of %v", got, maxSuggestions, want)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	s := newTestService(testCoins)
+	// Псевдоним пользователя перекрывает уникальный тикер ETH
+	s.aliases = map[string]string{"eth": "ethereum-classic", "btc": "bitcoin"}
+
+	tests := []struct {
+		name           string
+		input          string
+		want           string
+		wantCandidates []string // непусто - ожидается AmbiguousCoinError
+	}{
+		{name: "exact id", input: "ethereum", want: "ethereum"},
+		{name: "id with case and spaces", input: "  Solana ", want: "solana"},
+		{name: "alias takes precedence over ticker", input: "ETH", want: "ethereum-classic"},
+		{name: "alias", input: "btc", want: "bitcoin"},
+		{name: "unique ticker", input: "usdt", want: "tether"},
+		{name: "unique ticker upper case", input: "SOL", want: "solana"},
+		{name: "name", input: "Bitcoin Cash", want: "bitcoin-cash"},
+		{name: "ambiguous ticker", input: "UNI", wantCandidates: []string{"unicorn-token", "uniswap"}},
+		// Неизвестное значение возвращается как есть, проверку выполняет Validate
+		{name: "unknown", input: "DogeCoin", want: "dogecoin"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Resolve(context.Background(), tt.input)
+			if len(tt.wantCandidates) > 0 {
+				var ambiguous *AmbiguousCoinError
+				if !errors.As(err, &ambiguous) {
+					t.Fatalf("Resolve(%q) = %q, %v; want AmbiguousCoinError", tt.input, got, err)
+				}
+				var ids []string
+				for _, coin := range ambiguous.Candidates {
+					ids = append(ids, coin.ID)
+				}
+				if !reflect.DeepEqual(ids, tt.wantCandidates) || ambiguous.Input != tt.input {
+					t.Fatalf("candidates for %q = %v, want %v", ambiguous.Input, ids, tt.wantCandidates)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("Resolve(%q) = %q, %v; want %q", tt.input, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateUnknownCoin(t *testing.T) {
+	s := newTestService(testCoins)
+	err := s.Validate(context.Background(), "etherium")
+	var unknown *UnknownCoinError
+	if !errors.As(err, &unknown) {
+		t.Fatalf("Validate() error = %v, want UnknownCoinError", err)
+	}
+	if !reflect.DeepEqual(unknown.Suggestions, []string{"ethereum"}) {
+		t.Fatalf("suggestions = %v, want [ethereum]", unknown.Suggestions)
+	}
+	if err := s.Validate(context.Background(), "ethereum"); err != nil {
+		t.Fatalf("Validate(known) = %v", err)
+	}
+}
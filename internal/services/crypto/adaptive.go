@@ -0,0 +1,204 @@
+package crypto
+
+import (
+	"CryptoPriceCollection/internal/types"
+	"context"
+	"log"
+	"math"
+	"time"
+)
+
+const (
+	defaultAdaptiveMinInterval = 10 * time.Second
+	defaultAdaptiveMaxInterval = 10 * time.Minute
+	defaultAdaptiveThreshold   = 1.0
+	defaultAdaptiveWindow      = 15 * time.Minute
+)
+
+// adaptivePolling подстраивает интервал опроса монет под изменение цены за последнее окно:
+// при изменении больше порога монета опрашивается с минимальным интервалом, при спокойной цене интервал удваивается
+// до максимального. Суммарная частота опросов ограничена общим бюджетом.
+type adaptivePolling struct {
+	minInterval time.Duration
+	maxInterval time.Duration
+	threshold   float64
+	window      time.Duration
+	budget      float64
+	overBudget  bool // бюджет превышен даже при максимальных интервалах
+
+	desired   map[string]time.Duration // интервал по волатильности
+	effective map[string]time.Duration // интервал с учетом бюджета
+}
+
+// newAdaptivePolling создает адаптивный опрос; nil, если он выключен
+func newAdaptivePolling(cfg *types.ConfigAdaptive) *adaptivePolling {
+	if cfg == nil || !cfg.Enabled {
+		return nil
+	}
+	a := &adaptivePolling{
+		minInterval: time.Duration(cfg.MinInterval) * time.Millisecond,
+		maxInterval: time.Duration(cfg.MaxInterval) * time.Millisecond,
+		threshold:   cfg.Threshold,
+		window:      time.Duration(cfg.Window) * time.Minute,
+		budget:      cfg.Budget,
+		desired:     make(map[string]time.Duration),
+		effective:   make(map[string]time.Duration),
+	}
+	if a.minInterval < minFetchInterval {
+		a.minInterval = defaultAdaptiveMinInterval
+	}
+	if a.maxInterval < a.minInterval {
+		a.maxInterval = max(defaultAdaptiveMaxInterval, a.minInterval)
+	}
+	if a.threshold <= 0 {
+		a.threshold = defaultAdaptiveThreshold
+	}
+	if a.window <= 0 {
+		a.window = defaultAdaptiveWindow
+	}
+	return a
+}
+
+// interval текущий интервал опроса монеты; для новой монеты - base в пределах минимума и максимума
+func (a *adaptivePolling) interval(coin string, base time.Duration) time.Duration {
+	if interval, ok := a.effective[coin]; ok {
+		return interval
+	}
+	return min(max(base, a.minInterval), a.maxInterval)
+}
+
+// updateAdaptive пересчитывает интервалы монет по истории цен из currency_prices.
+// fixedRate - опросы в минуту, которые расходуют монеты с собственным расписанием, снимки и первые опросы.
+func (s *CryptoService) updateAdaptive(ctx context.Context, coins []string, fixedRate float64) {
+	a := s.adaptive
+	current := make(map[string]struct{}, len(coins))
+	for _, coin := range coins {
+		current[coin] = struct{}{}
+	}
+	for coin := range a.desired {
+		if _, ok := current[coin]; !ok {
+			delete(a.desired, coin)
+			delete(a.effective, coin)
+		}
+	}
+	if len(coins) == 0 {
+		return
+	}
+
+	since := time.Now().Add(-a.window).Unix()
+	ranges, err := s.repo.Crypto.Postgres.GetPriceRanges(ctx, coins, since)
+	if err != nil {
+		log.Printf("Error fetching price ranges for adaptive polling: %v", err)
+		return
+	}
+	changes := make(map[string]float64, len(coins))
+	for _, r := range ranges {
+		if r.Count < 2 || r.Low <= 0 {
+			continue
+		}
+		changes[r.Coin] = max(changes[r.Coin], (r.High-r.Low)/r.Low*100)
+	}
+
+	for _, coin := range coins {
+		interval, ok := a.desired[coin]
+		switch {
+		case !ok:
+			interval = a.interval(coin, s.fetchInterval)
+		case changes[coin] >= a.threshold:
+			interval = a.minInterval
+		case changes[coin] < a.threshold/2:
+			interval = min(interval*2, a.maxInterval)
+		}
+		a.desired[coin] = interval
+	}
+
+	for coin, interval := range a.budgeted(coins, fixedRate) {
+		if previous, ok := a.effective[coin]; ok && previous != interval {
+			log.Printf("Adaptive polling: %s interval %s -> %s (change %.2f%%)", coin, previous, interval, changes[coin])
+		}
+		a.effective[coin] = interval
+	}
+}
+
+// budgeted интервалы монет с учетом бюджета. Если монеты не укладываются в бюджет, желаемые интервалы
+// растягиваются в одинаковое число раз; монеты, упершиеся в максимальный интервал, расходуют бюджет
+// по максимальному интервалу, а остальные растягиваются сильнее. Если бюджет превышен и при максимальных
+// интервалах, все монеты опрашиваются с максимальным интервалом.
+func (a *adaptivePolling) budgeted(coins []string, fixedRate float64) map[string]time.Duration {
+	intervals := make(map[string]time.Duration, len(coins))
+	rate := 0.0
+	for _, coin := range coins {
+		intervals[coin] = a.desired[coin]
+		rate += perMinute(a.desired[coin])
+	}
+	if a.budget <= 0 || fixedRate+rate <= a.budget {
+		a.overBudget = false
+		return intervals
+	}
+
+	// Монеты с максимальным интервалом исключаются из растяжения, пока распределение не перестанет меняться
+	capped := make(map[string]bool, len(coins))
+	for {
+		available := a.budget - fixedRate
+		stretchable := 0.0
+		for _, coin := range coins {
+			if capped[coin] {
+				available -= perMinute(a.maxInterval)
+			} else {
+				stretchable += perMinute(a.desired[coin])
+			}
+		}
+		if stretchable == 0 {
+			break
+		}
+		if available <= 0 {
+			for _, coin := range coins {
+				intervals[coin] = a.maxInterval
+			}
+			break
+		}
+		scale := available / stretchable
+		changed := false
+		for _, coin := range coins {
+			if capped[coin] {
+				continue
+			}
+			// Округление вверх до секунды только уменьшает частоту
+			scaled := time.Duration(math.Ceil(float64(a.desired[coin])/scale/float64(time.Second))) * time.Second
+			if scaled >= a.maxInterval {
+				capped[coin] = true
+				changed = true
+			}
+			intervals[coin] = min(max(scaled, a.minInterval), a.maxInterval)
+		}
+		if !changed {
+			break
+		}
+	}
+
+	total := fixedRate
+	for _, interval := range intervals {
+		total += perMinute(interval)
+	}
+	overBudget := total > a.budget*(1+1e-9)
+	if overBudget && !a.overBudget {
+		log.Printf("Adaptive polling: %.2f polls per minute exceed ADAPTIVE_BUDGET %.2f even at ADAPTIVE_MAX_INTERVAL %s", total, a.budget, a.maxInterval)
+	}
+	a.overBudget = overBudget
+	return intervals
+}
+
+// perMinute число опросов в минуту при интервале interval
+func perMinute(interval time.Duration) float64 {
+	return float64(time.Minute) / float64(interval)
+}
+
+// fixedRate частота опросов в минуту для расписания, не управляемого адаптивным опросом
+func fixedRate(timing fetchTiming, now time.Time) float64 {
+	first := timing.Next(now)
+	second := timing.Next(first)
+	if first.IsZero() || second.IsZero() || !second.After(first) {
+		return 0
+	}
+	return perMinute(second.Sub(first))
+}
@@ -0,0 +1,126 @@
+package crypto
+
+import (
+	"CryptoPriceCollection/internal/types"
+	"CryptoPriceCollection/pkg/cron"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveBudgeted(t *testing.T) {
+	cfg := &types.ConfigAdaptive{Enabled: true, MinInterval: 10000, MaxInterval: 600000}
+	tests := []struct {
+		name           string
+		budget         float64
+		fixedRate      float64
+		desired        map[string]time.Duration
+		want           map[string]time.Duration
+		wantOverBudget bool
+	}{
+		{
+			name:    "no budget",
+			desired: map[string]time.Duration{"btc": 10 * time.Second, "eth": 10 * time.Second},
+			want:    map[string]time.Duration{"btc": 10 * time.Second, "eth": 10 * time.Second},
+		},
+		{
+			name:    "within budget",
+			budget:  12,
+			desired: map[string]time.Duration{"btc": 10 * time.Second, "eth": time.Minute},
+			want:    map[string]time.Duration{"btc": 10 * time.Second, "eth": time.Minute},
+		},
+		{
+			name:    "stretched proportionally",
+			budget:  6,
+			desired: map[string]time.Duration{"btc": 10 * time.Second, "eth": 10 * time.Second},
+			want:    map[string]time.Duration{"btc": 20 * time.Second, "eth": 20 * time.Second},
+		},
+		{
+			name:      "fixed schedules spend the budget first",
+			budget:    6,
+			fixedRate: 4,
+			desired:   map[string]time.Duration{"btc": 10 * time.Second, "eth": 20 * time.Second},
+			want:      map[string]time.Duration{"btc": 45 * time.Second, "eth": 90 * time.Second},
+		},
+		{
+			// Растяжение в 3 раза упирает doge в максимум 10 минут (0,1 опроса в минуту), и btc получает
+			// остаток бюджета 0,3 опроса в минуту вместо 1/3
+			name:    "capped coins leave less for the rest",
+			budget:  0.4,
+			desired: map[string]time.Duration{"btc": time.Minute, "doge": 5 * time.Minute},
+			want:    map[string]time.Duration{"btc": 200 * time.Second, "doge": 10 * time.Minute},
+		},
+		{
+			name:           "budget exceeded at max interval",
+			budget:         1,
+			fixedRate:      0.9,
+			desired:        map[string]time.Duration{"btc": time.Minute, "eth": time.Minute},
+			want:           map[string]time.Duration{"btc": 10 * time.Minute, "eth": 10 * time.Minute},
+			wantOverBudget: true,
+		},
+		{
+			name:           "fixed schedules exhaust the budget",
+			budget:         5,
+			fixedRate:      6,
+			desired:        map[string]time.Duration{"btc": 10 * time.Second},
+			want:           map[string]time.Duration{"btc": 10 * time.Minute},
+			wantOverBudget: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newAdaptivePolling(cfg)
+			a.budget = tt.budget
+			coins := make([]string, 0, len(tt.desired))
+			for coin, interval := range tt.desired {
+				a.desired[coin] = interval
+				coins = append(coins, coin)
+			}
+
+			got := a.budgeted(coins, tt.fixedRate)
+			for coin, want := range tt.want {
+				if got[coin] != want {
+					t.Errorf("%s interval = %s, want %s", coin, got[coin], want)
+				}
+			}
+			total := tt.fixedRate
+			for _, interval := range got {
+				total += perMinute(interval)
+			}
+			if tt.budget > 0 && !tt.wantOverBudget && total > tt.budget*(1+1e-9) {
+				t.Errorf("total rate %.3f exceeds budget %.3f", total, tt.budget)
+			}
+			if a.overBudget != tt.wantOverBudget {
+				t.Errorf("overBudget = %v, want %v", a.overBudget, tt.wantOverBudget)
+			}
+		})
+	}
+}
+
+func TestFixedRate(t *testing.T) {
+	hourly, err := cron.Parse("@hourly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	never, err := cron.Parse("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 5, 10, 10, 7, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		timing fetchTiming
+		want   float64
+	}{
+		{name: "every 10 seconds", timing: every(10 * time.Second), want: 6},
+		{name: "every 5 minutes", timing: every(5 * time.Minute), want: 0.2},
+		{name: "hourly cron", timing: hourly, want: 1.0 / 60},
+		{name: "never fires", timing: never, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fixedRate(tt.timing, now); got < tt.want-1e-9 || got > tt.want+1e-9 {
+				t.Fatalf("fixedRate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package crypto
+
+import (
+	"CryptoPriceCollection/internal/providers"
+	"CryptoPriceCollection/internal/providers/breaker"
+	"CryptoPriceCollection/internal/types"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Методы расчета консенсусной цены
+const (
+	ConsensusMedian      = "median"       // медиана
+	ConsensusTrimmedMean = "trimmed_mean" // среднее без крайних значений
+	ConsensusWeighted    = "weighted"     // среднее, взвешенное по источникам
+)
+
+// ConsensusSource источник, под которым сохраняется консенсусная цена
+const ConsensusSource = "consensus"
+
+// liveConfidence достоверность цены, полученной от поставщика при опросе или из потока
+const liveConfidence = 1.0
+
+// consensus настройки расчета консенсусной цены по нескольким источникам
+type consensus struct {
+	method       string
+	maxDeviation float64            // допустимое отклонение от медианы, %
+	trim         float64            // доля значений, отбрасываемых с каждого края
+	weights      map[string]float64 // вес источника для взвешенного среднего
+	minSources   int                // минимум источников, прошедших проверку
+}
+
+// newConsensus возвращает nil, если консенсус не настроен или метод неизвестен
+func newConsensus(cfg *types.ConfigConsensus) *consensus {
+	method := strings.ToLower(strings.TrimSpace(cfg.Method))
+	switch method {
+	case "":
+		return nil
+	case ConsensusMedian, ConsensusTrimmedMean, ConsensusWeighted:
+	default:
+		log.Printf("Unknown consensus method %q (expected %s, %s or %s), consensus is disabled",
+			cfg.Method, ConsensusMedian, ConsensusTrimmedMean, ConsensusWeighted)
+		return nil
+	}
+
+	weights := make(map[string]float64)
+	mapping, err := providers.ParseMapping(cfg.Weights)
+	if err != nil {
+		log.Printf("Invalid consensus weights, all sources weigh 1: %v", err)
+	}
+	for source, value := range mapping {
+		weight, err := strconv.ParseFloat(value, 64)
+		if err != nil || weight < 0 {
+			log.Printf("Invalid consensus weight %q for %s, using 1", value, source)
+			continue
+		}
+		weights[source] = weight
+	}
+
+	minSources := cfg.MinSources
+	if minSources < 1 {
+		minSources = 1
+	}
+	return &consensus{
+		method:       method,
+		maxDeviation: cfg.MaxDeviation,
+		trim:         cfg.Trim,
+		weights:      weights,
+		minSources:   minSources,
+	}
+}
+
+// price рассчитывает консенсусную цену и возвращает котировки, прошедшие проверку отклонения
+func (c *consensus) price(quotes []types.Quote) (float64, []types.Quote, error) {
+	if len(quotes) == 0 {
+		return 0, nil, fmt.Errorf("no quotes")
+	}
+
+	values := make([]float64, len(quotes))
+	for i, quote := range quotes {
+		values[i] = quote.Price
+	}
+	med := median(values)
+
+	accepted := make([]types.Quote, 0, len(quotes))
+	for _, quote := range quotes {
+		if c.maxDeviation > 0 && med != 0 {
+			deviation := math.Abs(quote.Price-med) / med * 100
+			if deviation > c.maxDeviation {
+				log.Printf("Rejected %s quote for %s: %.8g deviates %.2f%% from median %.8g",
+					quote.Source, quote.Coin, quote.Price, deviation, med)
+				continue
+			}
+		}
+		accepted = append(accepted, quote)
+	}
+	if len(accepted) < c.minSources {
+		return 0, accepted, fmt.Errorf("only %d of %d sources passed the deviation check, need %d",
+			len(accepted), len(quotes), c.minSources)
+	}
+
+	values = values[:0]
+	for _, quote := range accepted {
+		values = append(values, quote.Price)
+	}
+
+	switch c.method {
+	case ConsensusTrimmedMean:
+		return trimmedMean(values, c.trim), accepted, nil
+	case ConsensusWeighted:
+		var sum, total float64
+		for _, quote := range accepted {
+			weight, ok := c.weights[quote.Source]
+			if !ok {
+				weight = 1
+			}
+			sum += quote.Price * weight
+			total += weight
+		}
+		if total == 0 {
+			return median(values), accepted, nil
+		}
+		return sum / total, accepted, nil
+	default:
+		return median(values), accepted, nil
+	}
+}
+
+// pair монета и валюта котировки, по которым считается консенсус
+type pair struct {
+	coin  string
+	quote string
+}
+
+// fetchConsensus опрашивает всех поставщиков параллельно и рассчитывает консенсус по каждой паре монеты и валюты.
+// Возвращает консенсусные цены и исходные котировки всех источников.
+func (s *CryptoService) fetchConsensus(ctx context.Context, coins []string) ([]types.CurrencyPrice, error) {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		byPair   = make(map[pair][]types.Quote, len(coins))
+		failures int
+	)
+	for _, provider := range s.providers {
+		wg.Add(1)
+		go func(provider providers.PriceProvider) {
+			defer wg.Done()
+			quotes, err := provider.FetchPrices(ctx, coins)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if !errors.Is(err, breaker.ErrOpen) {
+					log.Printf("Error fetching prices from %s: %v", provider.Name(), err)
+				}
+				failures++
+				return
+			}
+			for _, quote := range quotes {
+				key := pair{coin: quote.Coin, quote: quote.Quote}
+				byPair[key] = append(byPair[key], quote)
+			}
+		}(provider)
+	}
+	wg.Wait()
+
+	if failures == len(s.providers) {
+		return nil, fmt.Errorf("all price providers failed")
+	}
+
+	timestamp := s.now().Unix()
+	var prices []types.CurrencyPrice
+	for key, quotes := range byPair {
+		for _, quote := range quotes {
+			raw := quoteToPrice(quote)
+			raw.Raw = true
+			prices = append(prices, raw)
+		}
+
+		price, accepted, err := s.consensus.price(quotes)
+		if err != nil {
+			log.Printf("No consensus price for %s/%s: %v", key.coin, key.quote, err)
+			continue
+		}
+		// Достоверность консенсуса - доля источников, прошедших проверку отклонения
+		confidence := float64(len(accepted)) / float64(len(quotes))
+		consensusPrice := types.CurrencyPrice{
+			Coin:       key.coin,
+			Quote:      key.quote,
+			Price:      price,
+			Timestamp:  timestamp,
+			Source:     ConsensusSource,
+			Confidence: &confidence,
+		}
+		// Рыночные данные берутся у первого принятого источника, который их сообщил
+		for _, quote := range accepted {
+			if consensusPrice.MarketCap == nil {
+				consensusPrice.MarketCap = quote.MarketCap
+			}
+			if consensusPrice.Volume24h == nil {
+				consensusPrice.Volume24h = quote.Volume24h
+			}
+			if consensusPrice.Change24h == nil {
+				consensusPrice.Change24h = quote.Change24h
+			}
+		}
+		prices = append(prices, consensusPrice)
+		if len(accepted) < len(quotes) {
+			log.Printf("Consensus for %s/%s used %d of %d sources", key.coin, key.quote, len(accepted), len(quotes))
+		}
+	}
+	return prices, nil
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n == 0 {
+		return 0
+	}
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// trimmedMean среднее после отбрасывания доли trim значений с каждого края
+func trimmedMean(values []float64, trim float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	cut := int(float64(len(sorted)) * trim)
+	if 2*cut >= len(sorted) {
+		return median(sorted)
+	}
+	sorted = sorted[cut : len(sorted)-cut]
+	var sum float64
+	for _, v := range sorted {
+		sum += v
+	}
+	return sum / float64(len(sorted))
+}
@@ -0,0 +1,176 @@
+package crypto
+
+import (
+	"CryptoPriceCollection/internal/types"
+	"math"
+	"testing"
+)
+
+func quotesOf(prices map[string]float64) []types.Quote {
+	quotes := make([]types.Quote, 0, len(prices))
+	for source, price := range prices {
+		quotes = append(quotes, types.Quote{Coin: "bitcoin", Quote: "usd", Price: price, Source: source})
+	}
+	return quotes
+}
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		want   float64
+	}{
+		{name: "empty", values: nil, want: 0},
+		{name: "single", values: []float64{5}, want: 5},
+		{name: "odd", values: []float64{3, 1, 2}, want: 2},
+		{name: "even", values: []float64{4, 1, 3, 2}, want: 2.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := append([]float64(nil), tt.values...)
+			if got := median(values); got != tt.want {
+				t.Fatalf("median(%v) = %v, want %v", tt.values, got, tt.want)
+			}
+			for i := range values {
+				if values[i] != tt.values[i] {
+					t.Fatalf("median modified its input: %v", values)
+				}
+			}
+		})
+	}
+}
+
+func TestTrimmedMean(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		trim   float64
+		want   float64
+	}{
+		{name: "no trim is the mean", values: []float64{1, 2, 3, 10}, trim: 0, want: 4},
+		{name: "drops one value from each side", values: []float64{100, 1, 2, 3, 4}, trim: 0.2, want: 3},
+		{name: "cut rounds down", values: []float64{1, 2, 3, 4}, trim: 0.2, want: 2.5},
+		{name: "trimming everything falls back to median", values: []float64{1, 2, 9}, trim: 0.5, want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := trimmedMean(tt.values, tt.trim); math.Abs(got-tt.want) > 1e-9 {
+				t.Fatalf("trimmedMean(%v, %v) = %v, want %v", tt.values, tt.trim, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConsensusPrice(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          types.ConfigConsensus
+		prices       map[string]float64
+		want         float64
+		wantAccepted int
+		wantErr      bool
+	}{
+		{
+			name:         "median",
+			cfg:          types.ConfigConsensus{Method: ConsensusMedian},
+			prices:       map[string]float64{"binance": 100, "kraken": 102, "coinbase": 101},
+			want:         101,
+			wantAccepted: 3,
+		},
+		{
+			name:         "outlier rejected by deviation from median",
+			cfg:          types.ConfigConsensus{Method: ConsensusMedian, MaxDeviation: 1},
+			prices:       map[string]float64{"binance": 100, "kraken": 100.5, "coinbase": 101, "coingecko": 120},
+			want:         100.5,
+			wantAccepted: 3,
+		},
+		{
+			name:         "trimmed mean",
+			cfg:          types.ConfigConsensus{Method: ConsensusTrimmedMean, Trim: 0.25},
+			prices:       map[string]float64{"binance": 100, "kraken": 102, "coinbase": 104, "coingecko": 200},
+			want:         103,
+			wantAccepted: 4,
+		},
+		{
+			name: "weighted",
+			cfg: types.ConfigConsensus{Method: ConsensusWeighted,
+				Weights: "binance:3,coingecko:1"},
+			prices:       map[string]float64{"binance": 100, "coingecko": 104},
+			want:         101,
+			wantAccepted: 2,
+		},
+		{
+			name:         "weighted with unknown source weighs 1",
+			cfg:          types.ConfigConsensus{Method: ConsensusWeighted, Weights: "binance:2"},
+			prices:       map[string]float64{"binance": 100, "kraken": 103},
+			want:         101,
+			wantAccepted: 2,
+		},
+		{
+			name:         "zero weights fall back to median",
+			cfg:          types.ConfigConsensus{Method: ConsensusWeighted, Weights: "binance:0,kraken:0"},
+			prices:       map[string]float64{"binance": 100, "kraken": 104},
+			want:         102,
+			wantAccepted: 2,
+		},
+		{
+			name:         "not enough sources after rejection",
+			cfg:          types.ConfigConsensus{Method: ConsensusMedian, MaxDeviation: 1, MinSources: 3},
+			prices:       map[string]float64{"binance": 100, "kraken": 100.5, "coingecko": 120},
+			wantAccepted: 2,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newConsensus(&tt.cfg)
+			got, accepted, err := c.price(quotesOf(tt.prices))
+			if len(accepted) != tt.wantAccepted {
+				t.Fatalf("accepted %d quotes, want %d", len(accepted), tt.wantAccepted)
+			}
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Fatalf("price = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewConsensusMethod(t *testing.T) {
+	tests := []struct {
+		method string
+		want   string // пусто - консенсус выключен
+	}{
+		{method: "", want: ""},
+		{method: "median", want: ConsensusMedian},
+		{method: "trimmed_mean", want: ConsensusTrimmedMean},
+		{method: "weighted", want: ConsensusWeighted},
+		{method: " Median ", want: ConsensusMedian},
+		{method: "mean", want: ""},
+		{method: "trimmed-mean", want: ""},
+	}
+	for _, tt := range tests {
+		c := newConsensus(&types.ConfigConsensus{Method: tt.method})
+		switch {
+		case tt.want == "" && c != nil:
+			t.Errorf("newConsensus(%q) = %+v, want disabled", tt.method, c)
+		case tt.want != "" && (c == nil || c.method != tt.want):
+			t.Errorf("newConsensus(%q) = %+v, want method %s", tt.method, c, tt.want)
+		}
+	}
+}
+
+func TestConsensusNoQuotes(t *testing.T) {
+	c := newConsensus(&types.ConfigConsensus{Method: ConsensusMedian})
+	if _, _, err := c.price(nil); err == nil {
+		t.Fatal("expected error for empty quotes")
+	}
+}
@@ -1,192 +1,354 @@
 package crypto
 
 import (
+	"CryptoPriceCollection/internal/providers"
+	"CryptoPriceCollection/internal/providers/breaker"
 	"CryptoPriceCollection/internal/repositories"
+	"CryptoPriceCollection/internal/spool"
 	"CryptoPriceCollection/internal/types"
-	"bytes"
+	"CryptoPriceCollection/pkg/cron"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"github.com/jackc/pgx/v5"
 	"log"
-	"net/http"
-	"strconv"
 	"strings"
 	"time"
 )
 
 type CryptoServiceInterface interface {
-	AddCurrency(ctx context.Context, coin string) error                                        // Добавление валюты в список наблюдаемых валют
-	RemoveCurrency(ctx context.Context, coin string) error                                     // Удаление валюты из списка наблюдаемых валю
-	GetPrice(ctx context.Context, coin string, timestamp *int64) (*types.CurrencyPrice, error) // Получение цены валюты
-	StartPriceFetcher(ctx context.Context)                                                     // Фоновое получение цен
+	AddCurrency(ctx context.Context, coin string, interval *int64, tier string) error                         // Добавление валюты в список наблюдаемых валют
+	RemoveCurrency(ctx context.Context, coin string) error                                                    // Удаление валюты из списка наблюдаемых валю
+	GetPrice(ctx context.Context, coin, quote, source string, timestamp *int64) (*types.CurrencyPrice, error) // Получение цены валюты
+	StartPriceFetcher(ctx context.Context)                                                                    // Фоновое получение цен
+	ProvidersStatus() []types.ProviderStatus                                                                  // Состояние поставщиков цен
 }
 
+type CoinResolver interface {
+	Resolve(ctx context.Context, input string) (string, error) // Приведение тикера, названия или псевдонима к идентификатору монеты
+	Validate(ctx context.Context, coin string) error           // Проверка, что монета есть в справочнике
+}
+
+type FXConverter interface {
+	Rate(ctx context.Context, currency string, timestamp int64) (*types.FXRate, error) // Курс USD к валюте, ближайший к заданному времени
+}
+
+type Backfiller interface {
+	ScheduleOnAdd(ctx context.Context, coin string) // Загрузка истории для только что добавленной валюты
+}
+
+// Режимы получения цен
+const (
+	IngestModePoll   = "poll"   // периодический опрос REST API
+	IngestModeStream = "stream" // потоковое получение через WebSocket
+	IngestModeBoth   = "both"   // опрос и поток одновременно
+)
+
 type CryptoService struct {
-	repo          repositories.Repositories
-	client        *http.Client
-	fetchInterval time.Duration
-	batchInterval time.Duration
-	apiBaseURL    string
-	prices        chan types.CurrencyPrice
+	repo            repositories.Repositories
+	providers       []providers.PriceProvider
+	streamers       []providers.PriceStreamer
+	fetchInterval   time.Duration
+	tiers           map[string]time.Duration
+	schedule        *cron.Schedule
+	snapshot        *cron.Schedule
+	alignTimestamps bool
+	adaptive        *adaptivePolling
+	spool           *spool.Spool
+	batch           batchSettings
+	ingestMode      string
+	consensus       *consensus
+	prices          chan types.CurrencyPrice
+	watchlist       *watchlistNotifier
+	resolver        CoinResolver
+	backfiller      Backfiller
+	fx              FXConverter
+	now             func() time.Time // время консенсусных цен
 }
 
-func NewCryptoService(repo repositories.Repositories, apiBaseURL string, fetchInterval, batchInterval time.Duration) *CryptoService {
+func NewCryptoService(repo repositories.Repositories, priceProviders []providers.PriceProvider, priceStreamers []providers.PriceStreamer, resolver CoinResolver, backfiller Backfiller, fx FXConverter, priceSpool *spool.Spool, cfgTasks *types.ConfigTasks, cfgAdaptive *types.ConfigAdaptive, cfgConsensus *types.ConfigConsensus) *CryptoService {
+	ingestMode := cfgTasks.IngestMode
+	if ingestMode == "" {
+		ingestMode = IngestModePoll
+	}
+	fetchInterval := legacyMilliseconds("FETCH_INTERVAL", cfgTasks.FetchInterval)
+	if fetchInterval <= 0 {
+		fetchInterval = defaultFetchInterval
+	}
+	schedule := parseSchedule("FETCH_SCHEDULE", cfgTasks.FetchSchedule)
+	adaptive := newAdaptivePolling(cfgAdaptive)
+	if schedule != nil && adaptive != nil {
+		log.Printf("ADAPTIVE_POLLING overrides FETCH_SCHEDULE %q: coins without interval and tier are polled adaptively", schedule)
+	}
 	return &CryptoService{
-		repo:          repo,
-		client:        &http.Client{Timeout: 10 * time.Second},
-		fetchInterval: fetchInterval,
-		batchInterval: batchInterval,
-		apiBaseURL:    apiBaseURL,
-		prices:        make(chan types.CurrencyPrice, 1000),
+		repo:            repo,
+		providers:       priceProviders,
+		streamers:       priceStreamers,
+		fetchInterval:   fetchInterval,
+		tiers:           parseTiers(cfgTasks.FetchTiers),
+		schedule:        schedule,
+		snapshot:        parseSchedule("SNAPSHOT_SCHEDULE", cfgTasks.SnapshotSchedule),
+		alignTimestamps: cfgTasks.AlignTimestamps,
+		adaptive:        adaptive,
+		spool:           priceSpool,
+		batch:           newBatchSettings(cfgTasks),
+		ingestMode:      ingestMode,
+		consensus:       newConsensus(cfgConsensus),
+		prices:          make(chan types.CurrencyPrice, 1000),
+		watchlist:       newWatchlistNotifier(),
+		resolver:        resolver,
+		backfiller:      backfiller,
+		fx:              fx,
+		now:             time.Now,
 	}
 }
 
-// StartPriceFetcher запускает фоновое получение цен
-func (s *CryptoService) StartPriceFetcher(ctx context.Context) {
-	go s.batchWriter(ctx)
-
-	ticker := time.NewTicker(s.fetchInterval)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			s.fetchAndStorePrices(ctx)
-		}
+// legacyMilliseconds переводит интервал из конфигурации в миллисекундах. Раньше FETCH_INTERVAL и BATCH_INTERVAL
+// задавались в секундах, поэтому значение меньше 1000 считается заданным в секундах, о чем пишется предупреждение.
+func legacyMilliseconds(name string, value int) time.Duration {
+	if value > 0 && value < 1000 {
+		log.Printf("%s=%d looks like seconds, interpreting it as %ds; set the value in milliseconds (%d)", name, value, value, value*1000)
+		return time.Duration(value) * time.Second
 	}
+	return time.Duration(value) * time.Millisecond
 }
 
-// batchWriter обрабатывает пакетные вставки в БД
-func (s *CryptoService) batchWriter(ctx context.Context) {
-	ticker := time.NewTicker(s.batchInterval)
-	defer ticker.Stop()
+// SetClock задает источник времени консенсусных цен, например фиксированное время при воспроизведении записанных обменов
+func (s *CryptoService) SetClock(now func() time.Time) {
+	s.now = now
+}
 
-	var batch []types.CurrencyPrice
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case price := <-s.prices:
-			batch = append(batch, price)
-		case <-ticker.C:
-			if len(batch) > 0 {
-				if err := s.repo.Crypto.Postgres.StoreBatch(ctx, batch); err != nil {
-					log.Printf("Error storing batch: %v", err)
-				}
-				batch = nil
-			}
+// StartPriceFetcher запускает фоновое получение цен; после отмены ctx возвращается, когда буфер цен записан в БД
+func (s *CryptoService) StartPriceFetcher(ctx context.Context) {
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		s.batchWriter(ctx)
+	}()
+	defer func() { <-writerDone }()
+
+	if s.ingestMode == IngestModeStream || s.ingestMode == IngestModeBoth {
+		for _, streamer := range s.streamers {
+			go s.runStream(ctx, streamer)
 		}
 	}
+	if s.ingestMode == IngestModeStream {
+		<-ctx.Done()
+		return
+	}
+
+	s.runScheduler(ctx)
 }
 
-// fetchAndStorePrices извлекает и сохраняет цены для отслеживаемых валют
-func (s *CryptoService) fetchAndStorePrices(ctx context.Context) {
-	coins, err := s.repo.Crypto.Postgres.GetWatchedCurrencies(ctx)
-	if err != nil {
-		log.Printf("Error fetching watched currencies: %v", err)
+// fetchAndStorePrices извлекает и сохраняет цены для монет, срок опроса которых наступил.
+// При FETCH_ALIGN_TIMESTAMPS цены получают время слота расписания slot вместо времени поставщика,
+// чтобы цены разных монет за один слот сравнивались точно.
+func (s *CryptoService) fetchAndStorePrices(ctx context.Context, coins []string, slot time.Time) {
+	if len(coins) == 0 {
 		return
 	}
+	stamp := func(price types.CurrencyPrice) types.CurrencyPrice {
+		if s.alignTimestamps && !slot.IsZero() {
+			price.Timestamp = slot.Unix()
+		}
+		return price
+	}
 
-	if len(coins) == 0 {
+	if s.consensus != nil && len(s.providers) > 1 {
+		prices, err := s.fetchConsensus(ctx, coins)
+		if err != nil {
+			log.Printf("Error fetching prices: %v", err)
+			return
+		}
+		for _, price := range prices {
+			s.enqueue(ctx, stamp(price))
+		}
 		return
 	}
 
-	prices, err := s.fetchPricesFromAPI(coins)
+	quotes, err := s.fetchPrices(ctx, coins)
 	if err != nil {
 		log.Printf("Error fetching prices: %v", err)
 		return
 	}
 
-	timestamp := time.Now().Unix()
-	for coin, price := range prices {
-		s.prices <- types.CurrencyPrice{
-			Coin:      coin,
-			Price:     price,
-			Timestamp: timestamp,
-		}
+	for _, quote := range quotes {
+		s.enqueue(ctx, stamp(quoteToPrice(quote)))
 	}
 }
 
-// fetchPricesFromAPI выводит цены на несколько монет
-func (s *CryptoService) fetchPricesFromAPI(coins []string) (map[string]float64, error) {
-	url := fmt.Sprintf("%s/simple/price?ids=%s&vs_currencies=usd", s.apiBaseURL, strings.Join(coins, ","))
-	resp, err := s.client.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("HTTP request error to CoinGecko: %w", err)
-	}
-	if resp.StatusCode == 429 {
-		log.Printf("Rate limit exceeded, pause for 60 seconds")
-		time.Sleep(60 * time.Second)
-		return nil, fmt.Errorf("rate limit exceeded")
-	}
-	defer resp.Body.Close()
+// fetchPrices опрашивает поставщиков по порядку; следующий поставщик запрашивается только по монетам,
+// цен которых еще нет.
+// Поставщики с разомкнутым выключателем пропускаются без обращения к API.
+func (s *CryptoService) fetchPrices(ctx context.Context, coins []string) ([]types.Quote, error) {
+	var result []types.Quote
+	var lastErr error
+	missing := coins
+	for _, provider := range s.providers {
+		if len(missing) == 0 {
+			break
+		}
+		quotes, err := provider.FetchPrices(ctx, missing)
+		if errors.Is(err, breaker.ErrOpen) {
+			lastErr = err
+			continue
+		}
+		if err != nil {
+			log.Printf("Error fetching prices from %s: %v", provider.Name(), err)
+			lastErr = err
+			continue
+		}
+		result = append(result, quotes...)
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error reading the API response: %w", err)
-	}
-	log.Printf("Response CoinGecko API: %s", string(body))
-
-	var result map[string]map[string]interface{}
-	if err := json.NewDecoder(bytes.NewReader(body)).Decode(&result); err != nil {
-		return nil, fmt.Errorf("decoding error JSON: %w", err)
-	}
-
-	prices := make(map[string]float64)
-	for _, coin := range coins {
-		if priceData, ok := result[coin]; ok {
-			if usd, ok := priceData["usd"]; ok {
-				switch v := usd.(type) {
-				case float64:
-					prices[coin] = v
-				case string:
-					price, err := strconv.ParseFloat(v, 64)
-					if err != nil {
-						log.Printf("Error converting the price for %s: %v", coin, err)
-						continue
-					}
-					prices[coin] = price
-				default:
-					log.Printf("Unsupported price type for %s: %T", coin, v)
-					continue
-				}
-			} else {
-				log.Printf("The USD price was not found for %s", coin)
+		// Монеты, которых нет у поставщика (например, не сопоставлены паре биржи), запрашиваются у следующего
+		received := make(map[string]struct{}, len(quotes))
+		for _, quote := range quotes {
+			received[quote.Coin] = struct{}{}
+		}
+		var rest []string
+		for _, coin := range missing {
+			if _, ok := received[coin]; !ok {
+				rest = append(rest, coin)
 			}
-		} else {
-			log.Printf("No data was found for %s", coin)
 		}
+		missing = rest
+	}
+
+	if len(result) == 0 && lastErr != nil {
+		return nil, fmt.Errorf("all price providers failed: %w", lastErr)
 	}
-	return prices, nil
+	if len(missing) > 0 {
+		log.Printf("No price provider returned prices for %v", missing)
+	}
+	return result, nil
 }
 
-// AddCurrency добавляет валюту в список отслеживаемых валют
-func (s *CryptoService) AddCurrency(ctx context.Context, coin string) error {
-	err := s.repo.Crypto.Postgres.AddCurrency(ctx, coin)
+// AddCurrency добавляет валюту в список отслеживаемых валют с собственным интервалом опроса или уровнем приоритета
+func (s *CryptoService) AddCurrency(ctx context.Context, coin string, interval *int64, tier string) error {
+	if err := s.validateSchedule(interval, tier); err != nil {
+		return err
+	}
+	coin, err := s.resolve(ctx, coin)
+	if err != nil {
+		return fmt.Errorf("couldn't add currency: %w", err)
+	}
+	if s.resolver != nil {
+		if err := s.resolver.Validate(ctx, coin); err != nil {
+			return fmt.Errorf("couldn't add currency: %w", err)
+		}
+	}
+	err = s.repo.Crypto.Postgres.AddCurrency(ctx, coin, interval, tier)
 	if err != nil {
 		log.Printf("Error in the repository when adding currency %s: %v", coin, err)
 		return fmt.Errorf("couldn't add currency: %w", err)
 	}
+	s.watchlist.Notify()
+	if s.backfiller != nil {
+		s.backfiller.ScheduleOnAdd(ctx, coin)
+	}
 	return nil
 }
 
 // RemoveCurrency удаляет валюту из списка отслеживаемых валют
 func (s *CryptoService) RemoveCurrency(ctx context.Context, coin string) error {
-	err := s.repo.Crypto.Postgres.RemoveCurrency(ctx, coin)
+	coin, err := s.resolve(ctx, coin)
+	if err != nil {
+		return fmt.Errorf("couldn't delete currency: %w", err)
+	}
+	err = s.repo.Crypto.Postgres.RemoveCurrency(ctx, coin)
 	if err != nil {
 		log.Printf("Error in the repository when deleting currency %s: %v", coin, err)
 		return fmt.Errorf("couldn't delete currency: %w", err)
 	}
+	s.watchlist.Notify()
 	return nil
 }
 
-// GetPrice извлекает цену монеты, либо самую последнюю, либо на определенную временную метку
-func (s *CryptoService) GetPrice(ctx context.Context, coin string, timestamp *int64) (*types.CurrencyPrice, error) {
+// GetPrice извлекает цену монеты, либо самую последнюю, либо на определенную временную метку.
+// Без source возвращается итоговая цена, иначе котировка указанного источника. Без quote цена берется в usd.
+func (s *CryptoService) GetPrice(ctx context.Context, coin, quote, source string, timestamp *int64) (*types.CurrencyPrice, error) {
+	coin, err := s.resolve(ctx, coin)
+	if err != nil {
+		return nil, err
+	}
+	if quote == "" {
+		quote = types.DefaultQuote
+	}
+	quote = strings.ToLower(quote)
+
+	price, err := s.getStoredPrice(ctx, coin, quote, source, timestamp)
+	if !errors.Is(err, pgx.ErrNoRows) || quote == types.DefaultQuote || s.fx == nil {
+		return price, err
+	}
+
+	// Котировки в этой валюте не собираются: пересчитываем цену в USD по ближайшему курсу
+	usdPrice, err := s.getStoredPrice(ctx, coin, types.DefaultQuote, source, timestamp)
+	if err != nil {
+		return nil, err
+	}
+	rate, err := s.fx.Rate(ctx, quote, usdPrice.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+	return convertPrice(usdPrice, quote, rate.Rate), nil
+}
+
+// getStoredPrice возвращает последнюю сохраненную цену либо ближайшую к timestamp
+func (s *CryptoService) getStoredPrice(ctx context.Context, coin, quote, source string, timestamp *int64) (*types.CurrencyPrice, error) {
 	if timestamp == nil {
-		return s.repo.Crypto.Postgres.GetLatestPrice(ctx, coin)
+		return s.repo.Crypto.Postgres.GetLatestPrice(ctx, coin, quote, source)
+	}
+	return s.repo.Crypto.Postgres.GetPrice(ctx, coin, quote, source, *timestamp)
+}
+
+// convertPrice пересчитывает цену в USD в другую валюту; изменение за 24 часа в процентах от курса не зависит
+func convertPrice(price *types.CurrencyPrice, quote string, rate float64) *types.CurrencyPrice {
+	converted := *price
+	converted.Quote = quote
+	converted.Price = price.Price * rate
+	converted.FXRate = &rate
+	if price.MarketCap != nil {
+		marketCap := *price.MarketCap * rate
+		converted.MarketCap = &marketCap
+	}
+	if price.Volume24h != nil {
+		volume := *price.Volume24h * rate
+		converted.Volume24h = &volume
+	}
+	return &converted
+}
+
+// resolve приводит тикер, название или псевдоним к идентификатору монеты
+func (s *CryptoService) resolve(ctx context.Context, coin string) (string, error) {
+	if s.resolver == nil {
+		return coin, nil
+	}
+	return s.resolver.Resolve(ctx, coin)
+}
+
+// ProvidersStatus возвращает состояние автоматических выключателей поставщиков в порядке приоритета
+func (s *CryptoService) ProvidersStatus() []types.ProviderStatus {
+	statuses := make([]types.ProviderStatus, 0, len(s.providers))
+	for _, provider := range s.providers {
+		if reporter, ok := provider.(providers.StatusReporter); ok {
+			statuses = append(statuses, reporter.Status())
+		}
+	}
+	return statuses
+}
+
+// quoteToPrice преобразует котировку поставщика в запись для БД
+func quoteToPrice(quote types.Quote) types.CurrencyPrice {
+	confidence := liveConfidence
+	return types.CurrencyPrice{
+		Coin:       quote.Coin,
+		Quote:      quote.Quote,
+		Price:      quote.Price,
+		Timestamp:  quote.Timestamp,
+		Source:     quote.Source,
+		MarketCap:  quote.MarketCap,
+		Volume24h:  quote.Volume24h,
+		Change24h:  quote.Change24h,
+		Confidence: &confidence,
 	}
-	return s.repo.Crypto.Postgres.GetPrice(ctx, coin, *timestamp)
 }
@@ -0,0 +1,40 @@
+package crypto
+
+import (
+	"CryptoPriceCollection/internal/repositories"
+	"CryptoPriceCollection/internal/types"
+	"testing"
+	"time"
+)
+
+func TestLegacyMilliseconds(t *testing.T) {
+	tests := []struct {
+		name  string
+		value int
+		want  time.Duration
+	}{
+		{name: "unset", value: 0, want: 0},
+		{name: "milliseconds", value: 60000, want: time.Minute},
+		{name: "one second in milliseconds", value: 1000, want: time.Second},
+		{name: "old seconds value", value: 60, want: time.Minute},
+		{name: "old one second value", value: 1, want: time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := legacyMilliseconds("FETCH_INTERVAL", tt.value); got != tt.want {
+				t.Fatalf("legacyMilliseconds(%d) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIntervalSettings(t *testing.T) {
+	cfg := &types.ConfigTasks{FetchInterval: 30, BatchInterval: 5000}
+	s := NewCryptoService(repositories.Repositories{}, nil, nil, nil, nil, nil, nil, cfg, &types.ConfigAdaptive{}, &types.ConfigConsensus{})
+	if s.fetchInterval != 30*time.Second {
+		t.Errorf("fetch interval = %s, want 30s", s.fetchInterval)
+	}
+	if s.batch.maxAge != 5*time.Second {
+		t.Errorf("batch interval = %s, want 5s", s.batch.maxAge)
+	}
+}
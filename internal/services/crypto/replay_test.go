@@ -0,0 +1,133 @@
+package crypto
+
+import (
+	"CryptoPriceCollection/internal/providers"
+	"CryptoPriceCollection/internal/providers/binance"
+	"CryptoPriceCollection/internal/providers/coingecko"
+	"CryptoPriceCollection/internal/providers/httpclient"
+	"CryptoPriceCollection/internal/providers/kraken"
+	"CryptoPriceCollection/internal/providers/recorder"
+	"CryptoPriceCollection/internal/types"
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// storedPrice поля цены, по которым сравниваются результаты воспроизведения
+type storedPrice struct {
+	Source    string
+	Coin      string
+	Price     float64
+	Timestamp int64
+	Raw       bool
+}
+
+// Время опроса в записанных обменах testdata/fixtures; им же помечаются котировки Kraken, Binance без closeTime
+// и консенсусные цены
+var replayClock = time.Date(2024, 5, 10, 10, 0, 30, 0, time.UTC)
+
+func TestFetchAndStorePricesReplay(t *testing.T) {
+	clock := replayClock.Unix()
+	tests := []struct {
+		name      string
+		consensus string
+		order     []string
+		want      []storedPrice
+	}{
+		{
+			name:      "consensus",
+			consensus: ConsensusMedian,
+			order:     []string{coingecko.Name, binance.Name, kraken.Name},
+			want: []storedPrice{
+				{Source: binance.Name, Coin: "bitcoin", Price: 67100, Timestamp: 1715335199, Raw: true},
+				{Source: binance.Name, Coin: "ethereum", Price: 3010, Timestamp: clock, Raw: true},
+				{Source: coingecko.Name, Coin: "bitcoin", Price: 67000, Timestamp: 1715335200, Raw: true},
+				{Source: coingecko.Name, Coin: "ethereum", Price: 3000, Timestamp: 1715335210, Raw: true},
+				{Source: coingecko.Name, Coin: "solana", Price: 150, Timestamp: 1715335220, Raw: true},
+				{Source: ConsensusSource, Coin: "bitcoin", Price: 67000, Timestamp: clock},
+				{Source: ConsensusSource, Coin: "ethereum", Price: 3000, Timestamp: clock},
+				{Source: ConsensusSource, Coin: "solana", Price: 150, Timestamp: clock},
+				{Source: kraken.Name, Coin: "bitcoin", Price: 66900, Timestamp: clock, Raw: true},
+				{Source: kraken.Name, Coin: "ethereum", Price: 2990, Timestamp: clock, Raw: true},
+			},
+		},
+		{
+			// solana нет ни на Binance, ни на Kraken, поэтому она запрашивается у CoinGecko отдельно
+			name:  "fallback",
+			order: []string{binance.Name, kraken.Name, coingecko.Name},
+			want: []storedPrice{
+				{Source: binance.Name, Coin: "bitcoin", Price: 67100, Timestamp: 1715335199},
+				{Source: binance.Name, Coin: "ethereum", Price: 3010, Timestamp: clock},
+				{Source: coingecko.Name, Coin: "solana", Price: 150, Timestamp: 1715335220},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newReplayService(t, tt.order, tt.consensus)
+			// Повторное воспроизведение тех же обменов дает те же цены
+			for run := 1; run <= 2; run++ {
+				s.fetchAndStorePrices(context.Background(), []string{"bitcoin", "ethereum", "solana"}, time.Time{})
+				if got := drainPrices(s); !reflect.DeepEqual(got, tt.want) {
+					t.Fatalf("run %d stored %+v, want %+v", run, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// newReplayService сервис с поставщиками, которые отвечают из testdata/fixtures без обращения к сети
+func newReplayService(t *testing.T, order []string, method string) *CryptoService {
+	t.Helper()
+	client := httpclient.New(&types.ConfigAPIClient{
+		RecordMode:  recorder.ModeReplay,
+		FixturesDir: "testdata/fixtures",
+		ReplayMatch: recorder.MatchKey,
+	}, nil)
+	now := func() time.Time { return replayClock }
+
+	// Части списка монет CoinGecko запрашиваются параллельно
+	gecko := coingecko.New(coingecko.Settings{ChunkSize: 2, ChunkWorkers: 2}, client)
+	bn := binance.New("", map[string]string{"bitcoin": "BTCUSDT", "ethereum": "ETHUSDT"}, client)
+	bn.SetClock(now)
+	kr := kraken.New("", map[string]string{"bitcoin": "XXBTZUSD", "ethereum": "XETHZUSD"}, client)
+	kr.SetClock(now)
+	byName := map[string]providers.PriceProvider{coingecko.Name: gecko, binance.Name: bn, kraken.Name: kr}
+
+	s := &CryptoService{
+		consensus: newConsensus(&types.ConfigConsensus{Method: method}),
+		prices:    make(chan types.CurrencyPrice, 100),
+	}
+	for _, name := range order {
+		s.providers = append(s.providers, byName[name])
+	}
+	s.SetClock(now)
+	return s
+}
+
+// drainPrices вычитывает цены, поставленные в очередь на запись, в порядке источника и монеты
+func drainPrices(s *CryptoService) []storedPrice {
+	var stored []storedPrice
+	for {
+		select {
+		case price := <-s.prices:
+			stored = append(stored, storedPrice{
+				Source:    price.Source,
+				Coin:      price.Coin,
+				Price:     price.Price,
+				Timestamp: price.Timestamp,
+				Raw:       price.Raw,
+			})
+		default:
+			sort.Slice(stored, func(i, j int) bool {
+				if stored[i].Source != stored[j].Source {
+					return stored[i].Source < stored[j].Source
+				}
+				return stored[i].Coin < stored[j].Coin
+			})
+			return stored
+		}
+	}
+}
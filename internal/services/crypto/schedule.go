@@ -0,0 +1,246 @@
+package crypto
+
+import (
+	"CryptoPriceCollection/internal/providers"
+	"CryptoPriceCollection/internal/types"
+	"CryptoPriceCollection/pkg/cron"
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Ошибки расписания опроса
+var (
+	ErrUnknownTier     = errors.New("unknown fetch tier")
+	ErrInvalidInterval = errors.New("invalid fetch interval")
+)
+
+const (
+	defaultFetchInterval = time.Minute
+	minFetchInterval     = time.Second
+	// scheduleSlack монеты, срок которых наступит в пределах этого окна, опрашиваются тем же запросом
+	scheduleSlack = time.Second
+)
+
+// parseTiers разбирает интервалы опроса по уровням вида high:10000,low:600000 (мс)
+func parseTiers(value string) map[string]time.Duration {
+	tiers := make(map[string]time.Duration)
+	mapping, err := providers.ParseMapping(value)
+	if err != nil {
+		log.Printf("Invalid FETCH_TIERS: %v", err)
+		return tiers
+	}
+	for tier, raw := range mapping {
+		ms, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || time.Duration(ms)*time.Millisecond < minFetchInterval {
+			log.Printf("Skipping fetch tier %s with invalid interval %q", tier, raw)
+			continue
+		}
+		tiers[tier] = time.Duration(ms) * time.Millisecond
+	}
+	return tiers
+}
+
+// parseSchedule разбирает выражение cron из конфигурации; некорректное или никогда не срабатывающее выражение игнорируется
+func parseSchedule(name, expr string) *cron.Schedule {
+	if expr == "" {
+		return nil
+	}
+	schedule, err := cron.Parse(expr)
+	if err != nil {
+		log.Printf("Invalid %s: %v", name, err)
+		return nil
+	}
+	if schedule.Next(time.Now().UTC()).IsZero() {
+		log.Printf("Invalid %s: %q never fires", name, expr)
+		return nil
+	}
+	return schedule
+}
+
+// validateSchedule проверяет расписание, переданное при добавлении валюты
+func (s *CryptoService) validateSchedule(interval *int64, tier string) error {
+	if interval != nil && time.Duration(*interval)*time.Millisecond < minFetchInterval {
+		return ErrInvalidInterval
+	}
+	if _, ok := s.tiers[tier]; tier != "" && !ok {
+		return ErrUnknownTier
+	}
+	return nil
+}
+
+// fetchTiming расписание опроса монеты
+type fetchTiming interface {
+	Next(t time.Time) time.Time
+}
+
+// every опрос с фиксированным интервалом, выровненным по настенным часам UTC (для 5m - :00, :05, :10 ...)
+type every time.Duration
+
+func (e every) Next(t time.Time) time.Time {
+	d := time.Duration(e)
+	return t.Truncate(d).Add(d)
+}
+
+// scheduleTiming расписание опроса монеты: собственный интервал, интервал уровня или общее расписание
+// (FETCH_SCHEDULE либо FETCH_INTERVAL)
+func (s *CryptoService) scheduleTiming(watched types.WatchedCurrency) fetchTiming {
+	if watched.FetchInterval != nil {
+		return every(max(time.Duration(*watched.FetchInterval)*time.Millisecond, minFetchInterval))
+	}
+	if interval, ok := s.tiers[watched.Tier]; ok {
+		return every(interval)
+	}
+	if s.adaptive != nil {
+		return every(s.adaptive.interval(watched.Coin, s.fetchInterval))
+	}
+	if s.schedule != nil {
+		return s.schedule
+	}
+	return every(s.fetchInterval)
+}
+
+// runScheduler опрашивает каждую монету по своему расписанию, объединяя монеты со сроком в одном окне в общий запрос.
+// Первый опрос выполняется сразу после запуска или добавления монеты, дальнейшие - в выровненные моменты времени.
+// По SNAPSHOT_SCHEDULE опрашиваются все отслеживаемые монеты разом.
+// Расписание и адаптивные интервалы перечитываются при изменении списка наблюдения и раз в FETCH_INTERVAL.
+func (s *CryptoService) runScheduler(ctx context.Context) {
+	nextDue := make(map[string]time.Time)
+	timings := make(map[string]fetchTiming)
+	// immediate монеты, ожидающие первого опроса вне выровненной сетки
+	immediate := make(map[string]struct{})
+	var nextSnapshot time.Time
+	if s.snapshot != nil {
+		nextSnapshot = s.snapshot.Next(time.Now().UTC())
+	}
+
+	load := func() {
+		watched, err := s.repo.Crypto.Postgres.GetWatchedSchedules(ctx)
+		if err != nil {
+			log.Printf("Error fetching watched currencies: %v", err)
+			return
+		}
+		now := time.Now().UTC()
+		if s.adaptive != nil {
+			var adaptiveCoins []string
+			rate := 0.0
+			for _, w := range watched {
+				if w.FetchInterval == nil && s.tiers[w.Tier] == 0 {
+					adaptiveCoins = append(adaptiveCoins, w.Coin)
+				} else {
+					rate += fixedRate(s.scheduleTiming(w), now)
+				}
+				// Первый опрос новой монеты выполняется сразу и расходует бюджет ближайшей минуты
+				if _, ok := nextDue[w.Coin]; !ok {
+					rate++
+				}
+			}
+			if s.snapshot != nil {
+				rate += fixedRate(s.snapshot, now) * float64(len(watched))
+			}
+			s.updateAdaptive(ctx, adaptiveCoins, rate)
+		}
+		current := make(map[string]struct{}, len(watched))
+		for _, w := range watched {
+			current[w.Coin] = struct{}{}
+			timing := s.scheduleTiming(w)
+			due, ok := nextDue[w.Coin]
+			switch {
+			case !ok:
+				// Новая монета опрашивается сразу, не дожидаясь полного интервала
+				due = now
+				immediate[w.Coin] = struct{}{}
+			case timing != timings[w.Coin]:
+				due = minTime(due, timing.Next(now))
+			}
+			nextDue[w.Coin] = due
+			timings[w.Coin] = timing
+		}
+		for coin := range nextDue {
+			if _, ok := current[coin]; !ok {
+				delete(nextDue, coin)
+				delete(timings, coin)
+				delete(immediate, coin)
+			}
+		}
+	}
+
+	load()
+	changed := s.watchlist.Changed()
+	reload := time.NewTicker(s.fetchInterval)
+	defer reload.Stop()
+
+	for {
+		earliest := nextSnapshot
+		for _, due := range nextDue {
+			if !due.IsZero() && (earliest.IsZero() || due.Before(earliest)) {
+				earliest = due
+			}
+		}
+		var timer *time.Timer
+		var wait <-chan time.Time
+		if !earliest.IsZero() {
+			timer = time.NewTimer(time.Until(earliest))
+			wait = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			stopTimer(timer)
+			return
+		case <-changed:
+			stopTimer(timer)
+			changed = s.watchlist.Changed()
+			load()
+		case <-reload.C:
+			stopTimer(timer)
+			load()
+		case fired := <-wait:
+			now := fired.UTC()
+			snapshot := !nextSnapshot.IsZero() && !nextSnapshot.After(now)
+			if snapshot {
+				nextSnapshot = s.snapshot.Next(now)
+			}
+			var due, first []string
+			for coin, at := range nextDue {
+				if at.IsZero() || (!snapshot && at.After(now.Add(scheduleSlack))) {
+					continue
+				}
+				if _, ok := immediate[coin]; ok && !snapshot {
+					first = append(first, coin)
+					delete(immediate, coin)
+				} else {
+					due = append(due, coin)
+				}
+				nextDue[coin] = timings[coin].Next(maxTime(now, at))
+			}
+			sort.Strings(first)
+			sort.Strings(due)
+			s.fetchAndStorePrices(ctx, first, time.Time{})
+			s.fetchAndStorePrices(ctx, due, earliest)
+		}
+	}
+}
+
+func stopTimer(timer *time.Timer) {
+	if timer != nil {
+		timer.Stop()
+	}
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,93 @@
+package crypto
+
+import (
+	"CryptoPriceCollection/internal/providers"
+	"CryptoPriceCollection/internal/types"
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// watchlistNotifier оповещает подписчиков об изменении списка наблюдаемых валют
+type watchlistNotifier struct {
+	mu      sync.Mutex
+	changed chan struct{}
+}
+
+func newWatchlistNotifier() *watchlistNotifier {
+	return &watchlistNotifier{changed: make(chan struct{})}
+}
+
+// Changed возвращает канал, который закроется при следующем изменении списка
+func (n *watchlistNotifier) Changed() <-chan struct{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.changed
+}
+
+// Notify оповещает всех ожидающих об изменении списка
+func (n *watchlistNotifier) Notify() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	close(n.changed)
+	n.changed = make(chan struct{})
+}
+
+// runStream передает поставщику актуальный список монет и пишет полученные котировки в пакетную вставку
+func (s *CryptoService) runStream(ctx context.Context, streamer providers.PriceStreamer) {
+	watchlist := make(chan []string, 1)
+	quotes := make(chan types.Quote, cap(s.prices))
+
+	go func() {
+		if err := streamer.StreamPrices(ctx, watchlist, quotes); err != nil && ctx.Err() == nil {
+			log.Printf("Price stream %s stopped: %v", streamer.Name(), err)
+		}
+	}()
+
+	changed := s.watchlist.Changed()
+	// Без первого списка поток не начнется, поэтому чтение повторяется с экспоненциальной задержкой, пока не удастся
+	for attempt := 1; ; attempt++ {
+		err := s.publishWatchlist(ctx, watchlist)
+		if err == nil {
+			break
+		}
+		delay := batchRetryDelay(attempt)
+		log.Printf("Error fetching watched currencies for stream %s (attempt %d), retrying in %s: %v", streamer.Name(), attempt, delay, err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-changed:
+			changed = s.watchlist.Changed()
+			// При ошибке поток продолжает работать по прежнему списку до следующего изменения
+			if err := s.publishWatchlist(ctx, watchlist); err != nil {
+				log.Printf("Error fetching watched currencies for stream %s: %v", streamer.Name(), err)
+			}
+		case quote := <-quotes:
+			s.enqueue(ctx, quoteToPrice(quote))
+		}
+	}
+}
+
+// publishWatchlist отправляет поставщику свежий список монет, заменяя еще не прочитанный
+func (s *CryptoService) publishWatchlist(ctx context.Context, watchlist chan []string) error {
+	coins, err := s.repo.Crypto.Postgres.GetWatchedCurrencies(ctx)
+	if err != nil {
+		return err
+	}
+	select {
+	case <-watchlist:
+	default:
+	}
+	watchlist <- coins
+	return nil
+}
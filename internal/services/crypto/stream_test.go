@@ -0,0 +1,123 @@
+package crypto
+
+import (
+	"CryptoPriceCollection/internal/repositories"
+	"CryptoPriceCollection/internal/repositories/crypto"
+	"CryptoPriceCollection/internal/repositories/crypto/postgresql"
+	"CryptoPriceCollection/internal/types"
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// watchedRepo хранилище, первые failures чтений списка наблюдаемых валют которого завершаются ошибкой
+type watchedRepo struct {
+	postgresql.CryptoRepository
+
+	mu       sync.Mutex
+	failures int
+	calls    int
+	coins    []string
+}
+
+func (r *watchedRepo) GetWatchedCurrencies(ctx context.Context) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls++
+	if r.failures > 0 {
+		r.failures--
+		return nil, errDatabaseDown
+	}
+	return r.coins, nil
+}
+
+func (r *watchedRepo) callCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls
+}
+
+// watchlistStreamer поставщик потока, который передает в received каждый полученный список монет
+type watchlistStreamer struct {
+	received chan []string
+}
+
+func (s *watchlistStreamer) Name() string {
+	return "test"
+}
+
+func (s *watchlistStreamer) StreamPrices(ctx context.Context, watchlist <-chan []string, out chan<- types.Quote) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case coins := <-watchlist:
+			s.received <- coins
+		}
+	}
+}
+
+func newStreamService(repo *watchedRepo) *CryptoService {
+	return &CryptoService{
+		repo:      repositories.Repositories{Crypto: &crypto.Crypto{Postgres: repo}},
+		prices:    make(chan types.CurrencyPrice, 10),
+		watchlist: newWatchlistNotifier(),
+	}
+}
+
+func TestRunStreamRetriesInitialWatchlist(t *testing.T) {
+	repo := &watchedRepo{failures: 1, coins: []string{"bitcoin", "ethereum"}}
+	s := newStreamService(repo)
+	streamer := &watchlistStreamer{received: make(chan []string, 1)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.runStream(ctx, streamer)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	select {
+	case coins := <-streamer.received:
+		if !reflect.DeepEqual(coins, repo.coins) {
+			t.Fatalf("streamer got %v, want %v", coins, repo.coins)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("the watchlist was not published after the database recovered")
+	}
+	if calls := repo.callCount(); calls != 2 {
+		t.Fatalf("GetWatchedCurrencies called %d times, want 2", calls)
+	}
+}
+
+func TestRunStreamStopsRetryingOnCancel(t *testing.T) {
+	repo := &watchedRepo{failures: 1000}
+	s := newStreamService(repo)
+	streamer := &watchlistStreamer{received: make(chan []string, 1)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.runStream(ctx, streamer)
+	}()
+
+	waitFor(t, time.Second, "the first read", func() bool { return repo.callCount() > 0 })
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("runStream kept retrying after the context was canceled")
+	}
+	select {
+	case coins := <-streamer.received:
+		t.Fatalf("streamer got %v without a successful read", coins)
+	default:
+	}
+}
@@ -0,0 +1,258 @@
+package crypto
+
+import (
+	"CryptoPriceCollection/internal/types"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	defaultBatchSize         = 1000
+	defaultBatchInterval     = time.Minute
+	defaultBatchMaxRetries   = 10
+	defaultBatchFlushTimeout = 10 * time.Second
+	batchRetryBase           = 500 * time.Millisecond
+	batchRetryMax            = 30 * time.Second
+)
+
+// batchSettings параметры пакетной записи цен
+type batchSettings struct {
+	size         int
+	maxAge       time.Duration
+	maxRetries   int
+	flushTimeout time.Duration
+}
+
+func newBatchSettings(cfg *types.ConfigTasks) batchSettings {
+	settings := batchSettings{
+		size:         cfg.BatchSize,
+		maxAge:       legacyMilliseconds("BATCH_INTERVAL", cfg.BatchInterval),
+		maxRetries:   cfg.BatchMaxRetries,
+		flushTimeout: time.Duration(cfg.BatchFlushTimeout) * time.Millisecond,
+	}
+	if settings.size <= 0 {
+		settings.size = defaultBatchSize
+	}
+	if settings.maxAge <= 0 {
+		settings.maxAge = defaultBatchInterval
+	}
+	if settings.maxRetries <= 0 {
+		settings.maxRetries = defaultBatchMaxRetries
+	}
+	if settings.flushTimeout <= 0 {
+		settings.flushTimeout = defaultBatchFlushTimeout
+	}
+	return settings
+}
+
+// batchRetryDelay экспоненциальная задержка перед повтором записи пакета
+func batchRetryDelay(attempt int) time.Duration {
+	return min(batchRetryBase<<min(attempt-1, 10), batchRetryMax)
+}
+
+// enqueue передает цену пакетной записи; после отмены ctx не ждет освобождения заполненного канала
+func (s *CryptoService) enqueue(ctx context.Context, price types.CurrencyPrice) {
+	select {
+	case s.prices <- price:
+		return
+	default:
+	}
+	select {
+	case s.prices <- price:
+	case <-ctx.Done():
+		log.Printf("Dropping price %s/%s from %s: shutting down", price.Coin, price.Quote, price.Source)
+	}
+}
+
+// batchWriter накапливает цены и записывает их пакетами: при достижении BATCH_SIZE цен или когда самой старой цене
+// в пакете исполняется BATCH_INTERVAL. Если БД недоступна и задан SPOOL_DIR, пакеты пишутся в журнал на диске,
+// а БД периодически проверяется воспроизведением журнала; пока журнал не воспроизведен полностью, новые пакеты
+// тоже идут в журнал, чтобы цены попали в БД в порядке получения. Без журнала неудачная запись повторяется
+// с экспоненциальной задержкой; пока пакет ждет повтора, он дополняется новыми ценами только до BATCH_SIZE,
+// после чего поставщики цен ждут освобождения канала.
+// При остановке канал цен вычитывается и буфер записывается в пределах BATCH_FLUSH_TIMEOUT.
+func (s *CryptoService) batchWriter(ctx context.Context) {
+	settings := s.batch
+	batch := make([]types.CurrencyPrice, 0, settings.size)
+	attempt := 0
+
+	timer := time.NewTimer(settings.maxAge)
+	timer.Stop()
+	defer timer.Stop()
+
+	// Оставшийся с прошлого запуска журнал воспроизводится сразу
+	spooling := s.spool != nil && s.spool.Pending()
+	replayAttempt := 0
+	replayTimer := time.NewTimer(0)
+	if !spooling {
+		replayTimer.Stop()
+	}
+	defer replayTimer.Stop()
+
+	done := func() {
+		batch = batch[:0]
+		attempt = 0
+		timer.Stop()
+	}
+
+	flush := func() {
+		if spooling {
+			err := s.spoolBatch(batch)
+			if err == nil {
+				done()
+				return
+			}
+			log.Printf("Error spooling batch of %d prices: %v", len(batch), err)
+		} else {
+			err := s.repo.Crypto.Postgres.StoreBatch(ctx, batch)
+			if err == nil {
+				done()
+				return
+			}
+			if ctx.Err() != nil {
+				// Запись прервана остановкой, пакет будет записан при финальном сбросе
+				return
+			}
+			if s.spool != nil {
+				spoolErr := s.spoolBatch(batch)
+				if spoolErr == nil {
+					log.Printf("Error storing batch, spooled %d prices to disk until the database recovers: %v", len(batch), err)
+					spooling = true
+					replayAttempt = 0
+					replayTimer.Reset(batchRetryDelay(1))
+					done()
+					return
+				}
+				log.Printf("Error spooling batch of %d prices: %v", len(batch), spoolErr)
+			}
+			log.Printf("Error storing batch of %d prices: %v", len(batch), err)
+		}
+
+		attempt++
+		if attempt > settings.maxRetries {
+			log.Printf("Dropping batch of %d prices after %d attempts", len(batch), attempt)
+			done()
+			return
+		}
+		delay := batchRetryDelay(attempt)
+		log.Printf("Retrying batch of %d prices (attempt %d) in %s", len(batch), attempt, delay)
+		timer.Reset(delay)
+	}
+
+	replay := func() {
+		err := s.replaySpool(ctx)
+		if err == nil {
+			log.Printf("Price spool replayed, writing to the database again")
+			spooling = false
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		replayAttempt++
+		delay := batchRetryDelay(replayAttempt)
+		log.Printf("Error replaying price spool (attempt %d), retrying in %s: %v", replayAttempt, delay, err)
+		replayTimer.Reset(delay)
+	}
+
+	for {
+		in := s.prices
+		if len(batch) >= settings.size {
+			in = nil
+		}
+
+		select {
+		case <-ctx.Done():
+			s.flushOnShutdown(batch, spooling)
+			return
+		case price := <-in:
+			if len(batch) == 0 && attempt == 0 {
+				timer.Reset(settings.maxAge)
+			}
+			batch = append(batch, price)
+			if len(batch) >= settings.size && attempt == 0 {
+				flush()
+			}
+		case <-timer.C:
+			flush()
+		case <-replayTimer.C:
+			replay()
+		}
+	}
+}
+
+// spoolBatch дописывает пакет цен в журнал на диске
+func (s *CryptoService) spoolBatch(batch []types.CurrencyPrice) error {
+	record, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("marshal batch: %w", err)
+	}
+	return s.spool.Append(record)
+}
+
+// replaySpool записывает в БД пакеты из журнала в порядке их записи
+func (s *CryptoService) replaySpool(ctx context.Context) error {
+	return s.spool.Replay(func(record []byte) error {
+		var batch []types.CurrencyPrice
+		if err := json.Unmarshal(record, &batch); err != nil {
+			log.Printf("Skipping unreadable spooled batch: %v", err)
+			return nil
+		}
+		return s.repo.Crypto.Postgres.StoreBatch(ctx, batch)
+	})
+}
+
+// flushOnShutdown вычитывает оставшиеся в канале цены и записывает буфер пакетами до истечения BATCH_FLUSH_TIMEOUT.
+// Если БД недоступна и журнал включен, буфер сохраняется в журнал и будет воспроизведен после запуска.
+func (s *CryptoService) flushOnShutdown(batch []types.CurrencyPrice, spooling bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.batch.flushTimeout)
+	defer cancel()
+
+	for drained := false; !drained; {
+		select {
+		case price := <-s.prices:
+			batch = append(batch, price)
+		default:
+			drained = true
+		}
+	}
+	if len(batch) == 0 {
+		return
+	}
+	log.Printf("Flushing %d buffered prices before shutdown", len(batch))
+
+	attempt := 0
+	for len(batch) > 0 {
+		chunk := batch[:min(len(batch), s.batch.size)]
+		if spooling {
+			if err := s.spoolBatch(chunk); err != nil {
+				log.Printf("Error spooling batch on shutdown, %d prices lost: %v", len(batch), err)
+				return
+			}
+			batch = batch[len(chunk):]
+			continue
+		}
+		if err := s.repo.Crypto.Postgres.StoreBatch(ctx, chunk); err != nil {
+			if s.spool != nil {
+				log.Printf("Error storing batch on shutdown, spooling %d prices: %v", len(batch), err)
+				spooling = true
+				continue
+			}
+			attempt++
+			delay := batchRetryDelay(attempt)
+			log.Printf("Error storing batch on shutdown (attempt %d): %v", attempt, err)
+			select {
+			case <-ctx.Done():
+				log.Printf("Shutdown flush deadline exceeded, %d prices lost", len(batch))
+				return
+			case <-time.After(delay):
+			}
+			continue
+		}
+		batch = batch[len(chunk):]
+		attempt = 0
+	}
+}
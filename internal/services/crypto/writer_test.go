@@ -0,0 +1,294 @@
+package crypto
+
+import (
+	"CryptoPriceCollection/internal/repositories"
+	"CryptoPriceCollection/internal/repositories/crypto"
+	"CryptoPriceCollection/internal/repositories/crypto/postgresql"
+	"CryptoPriceCollection/internal/spool"
+	"CryptoPriceCollection/internal/types"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+var errDatabaseDown = errors.New("database is down")
+
+// failingRepo хранилище цен, первые failures вызовов StoreBatch которого завершаются ошибкой
+type failingRepo struct {
+	postgresql.CryptoRepository
+
+	mu       sync.Mutex
+	failures int
+	calls    int
+	stored   [][]types.CurrencyPrice
+}
+
+func (r *failingRepo) StoreBatch(ctx context.Context, batch []types.CurrencyPrice) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls++
+	if r.failures > 0 {
+		r.failures--
+		return errDatabaseDown
+	}
+	r.stored = append(r.stored, append([]types.CurrencyPrice(nil), batch...))
+	return nil
+}
+
+func (r *failingRepo) snapshot() (calls int, stored [][]types.CurrencyPrice) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls, append([][]types.CurrencyPrice(nil), r.stored...)
+}
+
+func (r *failingRepo) setFailures(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failures = n
+}
+
+func newWriterService(repo *failingRepo, settings batchSettings, priceSpool *spool.Spool) *CryptoService {
+	return &CryptoService{
+		repo:   repositories.Repositories{Crypto: &crypto.Crypto{Postgres: repo}},
+		batch:  settings,
+		spool:  priceSpool,
+		prices: make(chan types.CurrencyPrice, 100),
+	}
+}
+
+// startWriter запускает batchWriter; возвращенная функция останавливает его и ждет финального сброса
+func startWriter(s *CryptoService) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.batchWriter(ctx)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func testPrices(from, n int) []types.CurrencyPrice {
+	prices := make([]types.CurrencyPrice, 0, n)
+	for i := from; i < from+n; i++ {
+		prices = append(prices, types.CurrencyPrice{Coin: fmt.Sprintf("coin-%d", i), Quote: "usd", Price: float64(i), Timestamp: int64(i), Source: "test"})
+	}
+	return prices
+}
+
+func send(s *CryptoService, prices []types.CurrencyPrice) {
+	for _, price := range prices {
+		s.prices <- price
+	}
+}
+
+// waitFor ждет выполнения условия, проверяя его до истечения timeout
+func waitFor(t *testing.T, timeout time.Duration, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestBatchWriterFlushesFullBatch(t *testing.T) {
+	repo := &failingRepo{}
+	s := newWriterService(repo, batchSettings{size: 3, maxAge: time.Hour, maxRetries: 3, flushTimeout: time.Second}, nil)
+	stop := startWriter(s)
+	defer stop()
+
+	prices := testPrices(0, 5)
+	send(s, prices)
+	waitFor(t, time.Second, "a full batch", func() bool {
+		_, stored := repo.snapshot()
+		return len(stored) == 1
+	})
+
+	// Оставшиеся две цены ждут либо BATCH_SIZE, либо BATCH_INTERVAL
+	time.Sleep(50 * time.Millisecond)
+	if _, stored := repo.snapshot(); len(stored) != 1 || !reflect.DeepEqual(stored[0], prices[:3]) {
+		t.Fatalf("stored %+v, want only the first %d prices", stored, 3)
+	}
+}
+
+func TestBatchWriterFlushesByAge(t *testing.T) {
+	repo := &failingRepo{}
+	maxAge := 100 * time.Millisecond
+	s := newWriterService(repo, batchSettings{size: 100, maxAge: maxAge, maxRetries: 3, flushTimeout: time.Second}, nil)
+	stop := startWriter(s)
+	defer stop()
+
+	started := time.Now()
+	prices := testPrices(0, 2)
+	send(s, prices)
+	waitFor(t, 2*time.Second, "an aged batch", func() bool {
+		_, stored := repo.snapshot()
+		return len(stored) == 1
+	})
+	if elapsed := time.Since(started); elapsed < maxAge {
+		t.Fatalf("batch flushed after %s, before BATCH_INTERVAL %s", elapsed, maxAge)
+	}
+	if _, stored := repo.snapshot(); !reflect.DeepEqual(stored[0], prices) {
+		t.Fatalf("stored %+v, want %+v", stored[0], prices)
+	}
+}
+
+func TestBatchWriterRetriesThenDrops(t *testing.T) {
+	tests := []struct {
+		name       string
+		failures   int
+		maxRetries int
+		wantCalls  int
+		wantStored bool
+	}{
+		// Первая попытка и повтор через batchRetryBase
+		{name: "recovers on retry", failures: 1, maxRetries: 1, wantCalls: 2, wantStored: true},
+		{name: "dropped after BATCH_MAX_RETRIES", failures: 100, maxRetries: 1, wantCalls: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &failingRepo{failures: tt.failures}
+			s := newWriterService(repo, batchSettings{size: 2, maxAge: time.Hour, maxRetries: tt.maxRetries, flushTimeout: time.Second}, nil)
+			stop := startWriter(s)
+			defer stop()
+
+			send(s, testPrices(0, 2))
+			waitFor(t, 3*time.Second, "retries", func() bool {
+				calls, _ := repo.snapshot()
+				return calls >= tt.wantCalls
+			})
+			// Брошенный пакет больше не записывается: следующий пакет пишется сам по себе
+			repo.setFailures(0)
+			next := testPrices(2, 2)
+			send(s, next)
+			waitFor(t, 3*time.Second, "the next batch", func() bool {
+				_, stored := repo.snapshot()
+				return len(stored) > 0 && reflect.DeepEqual(stored[len(stored)-1], next)
+			})
+
+			calls, stored := repo.snapshot()
+			if calls != tt.wantCalls+1 {
+				t.Errorf("StoreBatch called %d times, want %d", calls, tt.wantCalls+1)
+			}
+			if got := len(stored) == 2; got != tt.wantStored {
+				t.Errorf("stored batches %+v, first batch stored = %v, want %v", stored, got, tt.wantStored)
+			}
+		})
+	}
+}
+
+func TestBatchWriterSpoolsWhileDatabaseIsDown(t *testing.T) {
+	priceSpool, err := spool.Open(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer priceSpool.Close()
+
+	repo := &failingRepo{failures: 1}
+	s := newWriterService(repo, batchSettings{size: 2, maxAge: time.Hour, maxRetries: 1, flushTimeout: time.Second}, priceSpool)
+	stop := startWriter(s)
+	defer stop()
+
+	prices := testPrices(0, 2)
+	send(s, prices)
+	waitFor(t, time.Second, "the failed write", func() bool {
+		calls, _ := repo.snapshot()
+		return calls == 1
+	})
+	waitFor(t, time.Second, "the batch in the spool", priceSpool.Pending)
+
+	// Журнал воспроизводится через batchRetryBase и переносит пакет в БД
+	waitFor(t, 3*time.Second, "the spool replay", func() bool {
+		_, stored := repo.snapshot()
+		return len(stored) == 1
+	})
+	if _, stored := repo.snapshot(); !reflect.DeepEqual(stored[0], prices) {
+		t.Fatalf("replayed %+v, want %+v", stored[0], prices)
+	}
+	if priceSpool.Pending() {
+		t.Fatal("spool still has pending segments after a successful replay")
+	}
+
+	// После воспроизведения цены снова пишутся напрямую
+	next := testPrices(2, 2)
+	send(s, next)
+	waitFor(t, time.Second, "a direct write", func() bool {
+		_, stored := repo.snapshot()
+		return len(stored) == 2
+	})
+}
+
+func TestFlushOnShutdown(t *testing.T) {
+	tests := []struct {
+		name        string
+		size        int
+		failures    int
+		spool       bool
+		wantBatches []int // размеры записанных в БД пакетов
+		wantSpooled int   // цен в журнале после остановки
+	}{
+		{name: "drains the channel into one batch", size: 100, wantBatches: []int{5}},
+		{name: "splits by BATCH_SIZE", size: 2, wantBatches: []int{2, 2, 1}},
+		{name: "retries a failed write", size: 100, failures: 1, wantBatches: []int{5}},
+		{name: "spools when the database is down", size: 2, failures: 100, spool: true, wantSpooled: 5},
+		{name: "gives up at BATCH_FLUSH_TIMEOUT", size: 100, failures: 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var priceSpool *spool.Spool
+			dir := t.TempDir()
+			if tt.spool {
+				var err error
+				if priceSpool, err = spool.Open(dir, 0); err != nil {
+					t.Fatal(err)
+				}
+				defer priceSpool.Close()
+			}
+			repo := &failingRepo{failures: tt.failures}
+			s := newWriterService(repo, batchSettings{size: tt.size, maxAge: time.Hour, maxRetries: 1, flushTimeout: 700 * time.Millisecond}, priceSpool)
+
+			// Цены остаются в канале к моменту остановки и вычитываются финальным сбросом
+			send(s, testPrices(0, 5))
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			s.batchWriter(ctx)
+
+			_, stored := repo.snapshot()
+			var sizes []int
+			for _, batch := range stored {
+				sizes = append(sizes, len(batch))
+			}
+			if !reflect.DeepEqual(sizes, tt.wantBatches) {
+				t.Errorf("stored batches of %v prices, want %v", sizes, tt.wantBatches)
+			}
+
+			spooled := 0
+			if priceSpool != nil {
+				err := priceSpool.Replay(func(record []byte) error {
+					var batch []types.CurrencyPrice
+					if err := json.Unmarshal(record, &batch); err != nil {
+						return err
+					}
+					spooled += len(batch)
+					return nil
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if spooled != tt.wantSpooled {
+				t.Errorf("spooled %d prices, want %d", spooled, tt.wantSpooled)
+			}
+		})
+	}
+}
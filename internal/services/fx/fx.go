@@ -0,0 +1,76 @@
+package fx
+
+import (
+	"CryptoPriceCollection/internal/providers"
+	"CryptoPriceCollection/internal/repositories"
+	"CryptoPriceCollection/internal/types"
+	"context"
+	"log"
+	"strings"
+	"time"
+)
+
+type FXServiceInterface interface {
+	Rate(ctx context.Context, currency string, timestamp int64) (*types.FXRate, error) // Курс USD к валюте, ближайший к заданному времени
+	Start(ctx context.Context)                                                         // Фоновое получение курсов
+}
+
+const defaultFetchInterval = time.Hour
+
+type FXService struct {
+	repo          repositories.Repositories
+	providers     []providers.FXProvider
+	fetchInterval time.Duration
+}
+
+func NewFXService(repo repositories.Repositories, fxProviders []providers.FXProvider, cfgFX *types.ConfigFX) *FXService {
+	fetchInterval := time.Duration(cfgFX.FetchInterval) * time.Minute
+	if fetchInterval <= 0 {
+		fetchInterval = defaultFetchInterval
+	}
+	return &FXService{
+		repo:          repo,
+		providers:     fxProviders,
+		fetchInterval: fetchInterval,
+	}
+}
+
+// Rate возвращает курс USD к валюте, ближайший к заданному времени
+func (s *FXService) Rate(ctx context.Context, currency string, timestamp int64) (*types.FXRate, error) {
+	return s.repo.FX.Postgres.GetNearestRate(ctx, types.DefaultQuote, strings.ToLower(currency), timestamp)
+}
+
+// Start периодически получает курсы у всех поставщиков; курсы публикуются раз в день, поэтому опрос редкий
+func (s *FXService) Start(ctx context.Context) {
+	if len(s.providers) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.fetchInterval)
+	defer ticker.Stop()
+
+	for {
+		s.fetchAndStoreRates(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// fetchAndStoreRates получает и сохраняет курсы; ошибка одного поставщика не мешает остальным
+func (s *FXService) fetchAndStoreRates(ctx context.Context) {
+	for _, provider := range s.providers {
+		rates, err := provider.FetchRates(ctx)
+		if err != nil {
+			log.Printf("Error fetching FX rates from %s: %v", provider.Name(), err)
+			continue
+		}
+		if err := s.repo.FX.Postgres.StoreRates(ctx, rates); err != nil {
+			log.Printf("Error storing FX rates from %s: %v", provider.Name(), err)
+			continue
+		}
+		log.Printf("Stored %d FX rates from %s", len(rates), provider.Name())
+	}
+}
@@ -1,17 +1,34 @@
 package services
 
 import (
+	"CryptoPriceCollection/internal/providers"
 	"CryptoPriceCollection/internal/repositories"
+	"CryptoPriceCollection/internal/services/backfill"
+	"CryptoPriceCollection/internal/services/candles"
+	"CryptoPriceCollection/internal/services/coins"
 	"CryptoPriceCollection/internal/services/crypto"
-	"time"
+	"CryptoPriceCollection/internal/services/fx"
+	"CryptoPriceCollection/internal/spool"
+	"CryptoPriceCollection/internal/types"
 )
 
 type Service struct {
-	CryptoService crypto.CryptoServiceInterface
+	CryptoService   crypto.CryptoServiceInterface
+	BackfillService backfill.BackfillServiceInterface
+	CandlesService  candles.CandlesServiceInterface
+	CoinsService    coins.CoinsServiceInterface
+	FXService       fx.FXServiceInterface
 }
 
-func NewService(repo repositories.Repositories, apiBaseURL string, fetchInterval, batchInterval time.Duration) *Service {
+func NewService(repo repositories.Repositories, priceProviders []providers.PriceProvider, priceStreamers []providers.PriceStreamer, history providers.HistoryProvider, candleProvider providers.CandleProvider, coinList providers.CoinListProvider, fxProviders []providers.FXProvider, priceSpool *spool.Spool, cfgProviders *types.ConfigProviders, cfgTasks *types.ConfigTasks, cfgAdaptive *types.ConfigAdaptive, cfgConsensus *types.ConfigConsensus, cfgBackfill *types.ConfigBackfill, cfgCandles *types.ConfigCandles, cfgCoins *types.ConfigCoins, cfgFX *types.ConfigFX) *Service {
+	backfillService := backfill.NewBackfillService(repo, history, providers.QuoteCurrencies(cfgProviders), cfgBackfill)
+	coinsService := coins.NewCoinsService(repo, coinList, cfgCoins)
+	fxService := fx.NewFXService(repo, fxProviders, cfgFX)
 	return &Service{
-		CryptoService: crypto.NewCryptoService(repo, apiBaseURL, fetchInterval, batchInterval),
+		CryptoService:   crypto.NewCryptoService(repo, priceProviders, priceStreamers, coinsService, backfillService, fxService, priceSpool, cfgTasks, cfgAdaptive, cfgConsensus),
+		BackfillService: backfillService,
+		CandlesService:  candles.NewCandlesService(repo, candleProvider, cfgCandles),
+		CoinsService:    coinsService,
+		FXService:       fxService,
 	}
 }
@@ -0,0 +1,386 @@
+// Package spool реализует локальный журнал предзаписи (WAL): записи дописываются в сегменты на диске
+// с контрольными суммами и воспроизводятся в порядке записи.
+//
+// Формат записи: длина полезной нагрузки (uint32, big endian), CRC32-C нагрузки (uint32, big endian), нагрузка.
+// Сегменты называются по возрастающему номеру (0000000000000001.wal) и сменяются по достижении размера сегмента.
+// Прогресс воспроизведения хранится в файле checkpoint, поэтому после перезапуска уже записанные в БД
+// записи не воспроизводятся повторно. Сегмент с поврежденной записью в середине после воспроизведения
+// уцелевших записей не удаляется, а переименовывается в *.wal.corrupt для ручного разбора.
+package spool
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	segmentExt     = ".wal"
+	corruptExt     = segmentExt + ".corrupt"
+	checkpointFile = "checkpoint"
+	headerSize     = 8
+	// maxRecordSize защищает от чтения мусорной длины из поврежденного заголовка
+	maxRecordSize = 64 << 20
+	// DefaultSegmentSize размер сегмента по умолчанию
+	DefaultSegmentSize = 64 << 20
+)
+
+var (
+	crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+	errChecksum  = errors.New("checksum mismatch")
+	errTruncated = errors.New("truncated record")
+)
+
+// Spool журнал записей в каталоге на диске
+type Spool struct {
+	dir         string
+	segmentSize int64
+
+	mu         sync.Mutex
+	active     *os.File
+	activeSize int64
+	nextSeq    uint64
+}
+
+// Open открывает журнал в каталоге dir, создавая его при необходимости
+func Open(dir string, segmentSize int64) (*Spool, error) {
+	if segmentSize <= 0 {
+		segmentSize = DefaultSegmentSize
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create spool dir: %w", err)
+	}
+	s := &Spool{dir: dir, segmentSize: segmentSize}
+	s.nextSeq = 1
+	// Номера отложенных поврежденных сегментов тоже не переиспользуются
+	for _, ext := range []string{segmentExt, corruptExt} {
+		segments, err := s.list(ext)
+		if err != nil {
+			return nil, err
+		}
+		if len(segments) > 0 && segments[len(segments)-1] >= s.nextSeq {
+			s.nextSeq = segments[len(segments)-1] + 1
+		}
+	}
+	return s, nil
+}
+
+// Append дописывает запись в текущий сегмент и сбрасывает ее на диск
+func (s *Spool) Append(record []byte) error {
+	if len(record) > maxRecordSize {
+		return fmt.Errorf("spool record of %d bytes exceeds limit", len(record))
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	size := int64(headerSize + len(record))
+	if s.active != nil && s.activeSize > 0 && s.activeSize+size > s.segmentSize {
+		if err := s.closeActive(); err != nil {
+			return err
+		}
+	}
+	if s.active == nil {
+		f, err := os.OpenFile(s.segmentPath(s.nextSeq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("open spool segment: %w", err)
+		}
+		s.active = f
+		s.activeSize = 0
+		s.nextSeq++
+	}
+
+	buf := make([]byte, size)
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(record)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.Checksum(record, crcTable))
+	copy(buf[headerSize:], record)
+	if _, err := s.active.Write(buf); err != nil {
+		s.discardTail()
+		return fmt.Errorf("write spool record: %w", err)
+	}
+	if err := s.active.Sync(); err != nil {
+		s.discardTail()
+		return fmt.Errorf("sync spool segment: %w", err)
+	}
+	s.activeSize += size
+	return nil
+}
+
+// discardTail отрезает от текущего сегмента байты неудавшейся записи, чтобы следующая запись
+// начиналась на границе записей. Если обрезать не удалось, сегмент закрывается и следующая запись
+// пойдет в новый, а недописанный хвост будет пропущен при воспроизведении.
+func (s *Spool) discardTail() {
+	err := s.active.Truncate(s.activeSize)
+	if err == nil {
+		err = s.active.Sync()
+	}
+	if err == nil {
+		return
+	}
+	log.Printf("Error truncating spool segment %s to %d bytes: %v, rotating", filepath.Base(s.active.Name()), s.activeSize, err)
+	if err := s.closeActive(); err != nil {
+		log.Printf("Error rotating spool segment: %v", err)
+	}
+}
+
+// Pending сообщает, есть ли в журнале невоспроизведенные сегменты
+func (s *Spool) Pending() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	segments, err := s.pendingSegments()
+	if err != nil {
+		log.Printf("Error listing spool segments: %v", err)
+		return false
+	}
+	return len(segments) > 0
+}
+
+// Replay передает записи fn в порядке записи. Полностью воспроизведенные сегменты удаляются.
+// Если fn возвращает ошибку, воспроизведение останавливается и при следующем вызове продолжится с этой записи.
+// Недописанная запись в конце сегмента (сбой во время записи) пропускается. Запись с неверной контрольной
+// суммой пропускается по ее длине и воспроизведение сегмента продолжается; если длина в заголовке
+// недопустима, остаток сегмента не читается. В обоих случаях сегмент сохраняется как *.wal.corrupt.
+func (s *Spool) Replay(fn func(record []byte) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Текущий сегмент с данными закрывается, следующие записи пойдут в новый
+	if s.activeSize > 0 {
+		if err := s.closeActive(); err != nil {
+			return err
+		}
+	}
+	segments, err := s.pendingSegments()
+	if err != nil {
+		return err
+	}
+	cp, err := s.readCheckpoint()
+	if err != nil {
+		return err
+	}
+
+	for _, seq := range segments {
+		var start checkpoint
+		switch {
+		case seq < cp.seq:
+			// Сегмент воспроизведен, но не удален перед остановкой
+			if err := s.removeSegment(seq); err != nil {
+				return err
+			}
+			continue
+		case seq == cp.seq:
+			start = cp
+		}
+		damaged, err := s.replaySegment(seq, start, fn)
+		if err != nil {
+			return err
+		}
+		if damaged {
+			err = s.quarantineSegment(seq)
+		} else {
+			err = s.removeSegment(seq)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := os.Remove(filepath.Join(s.dir, checkpointFile)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove spool checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Close закрывает текущий сегмент
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closeActive()
+}
+
+// replaySegment воспроизводит сегмент с позиции start и сообщает, были ли в нем поврежденные записи
+func (s *Spool) replaySegment(seq uint64, start checkpoint, fn func(record []byte) error) (bool, error) {
+	f, err := os.Open(s.segmentPath(seq))
+	if err != nil {
+		return false, fmt.Errorf("open spool segment: %w", err)
+	}
+	defer f.Close()
+	offset, damaged := start.offset, start.damaged
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return false, fmt.Errorf("seek spool segment: %w", err)
+	}
+
+	name := filepath.Base(f.Name())
+	r := bufio.NewReader(f)
+	for {
+		record, length, err := readRecord(r)
+		switch {
+		case errors.Is(err, io.EOF):
+			return damaged, nil
+		case errors.Is(err, errTruncated):
+			log.Printf("Spool segment %s: %v at offset %d, skipping the torn tail", name, err, offset)
+			return damaged, nil
+		case errors.Is(err, errChecksum):
+			log.Printf("Spool segment %s: %v at offset %d, skipping %d bytes", name, err, offset, headerSize+length)
+			damaged = true
+		case err != nil:
+			log.Printf("Spool segment %s: %v at offset %d, cannot resync, keeping the segment", name, err, offset)
+			return true, nil
+		default:
+			if err := fn(record); err != nil {
+				return false, err
+			}
+		}
+		offset += int64(headerSize + length)
+		if err := s.writeCheckpoint(checkpoint{seq: seq, offset: offset, damaged: damaged}); err != nil {
+			return false, err
+		}
+	}
+}
+
+// readRecord читает одну запись и возвращает длину ее нагрузки из заголовка;
+// io.EOF означает конец сегмента на границе записей
+func readRecord(r io.Reader) ([]byte, int, error) {
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, 0, errTruncated
+		}
+		return nil, 0, err
+	}
+	length := binary.BigEndian.Uint32(header[0:4])
+	if length > maxRecordSize {
+		return nil, 0, fmt.Errorf("invalid record length %d", length)
+	}
+	record := make([]byte, length)
+	if _, err := io.ReadFull(r, record); err != nil {
+		return nil, 0, errTruncated
+	}
+	if crc32.Checksum(record, crcTable) != binary.BigEndian.Uint32(header[4:8]) {
+		return nil, int(length), errChecksum
+	}
+	return record, int(length), nil
+}
+
+func (s *Spool) closeActive() error {
+	if s.active == nil {
+		return nil
+	}
+	err := s.active.Close()
+	s.active = nil
+	s.activeSize = 0
+	if err != nil {
+		return fmt.Errorf("close spool segment: %w", err)
+	}
+	return nil
+}
+
+// pendingSegments номера сегментов для воспроизведения по возрастанию, кроме открытого пустого сегмента
+func (s *Spool) pendingSegments() ([]uint64, error) {
+	segments, err := s.list(segmentExt)
+	if err != nil {
+		return nil, err
+	}
+	if s.active != nil && s.activeSize == 0 && len(segments) > 0 && segments[len(segments)-1] == s.nextSeq-1 {
+		// Открытый сегмент всегда последний
+		segments = segments[:len(segments)-1]
+	}
+	return segments, nil
+}
+
+// list номера файлов журнала с расширением ext по возрастанию
+func (s *Spool) list(ext string) ([]uint64, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("read spool dir: %w", err)
+	}
+	var segments []uint64
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, ext), 10, 64)
+		if err != nil {
+			continue
+		}
+		segments = append(segments, seq)
+	}
+	sort.Slice(segments, func(i, j int) bool { return segments[i] < segments[j] })
+	return segments, nil
+}
+
+func (s *Spool) segmentPath(seq uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%016d%s", seq, segmentExt))
+}
+
+// quarantineSegment откладывает сегмент с поврежденными записями, чтобы он не воспроизводился повторно
+func (s *Spool) quarantineSegment(seq uint64) error {
+	path := s.segmentPath(seq)
+	corrupt := strings.TrimSuffix(path, segmentExt) + corruptExt
+	if err := os.Rename(path, corrupt); err != nil {
+		return fmt.Errorf("quarantine spool segment: %w", err)
+	}
+	log.Printf("Spool segment %s had corrupt records and was kept as %s", filepath.Base(path), filepath.Base(corrupt))
+	return nil
+}
+
+func (s *Spool) removeSegment(seq uint64) error {
+	if err := os.Remove(s.segmentPath(seq)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove spool segment: %w", err)
+	}
+	return nil
+}
+
+// checkpoint прогресс воспроизведения: сегмент, смещение первой невоспроизведенной записи и признак того,
+// что в уже воспроизведенной части сегмента были поврежденные записи
+type checkpoint struct {
+	seq     uint64
+	offset  int64
+	damaged bool
+}
+
+// readCheckpoint прогресс воспроизведения; нулевое значение, если воспроизведение не начиналось
+func (s *Spool) readCheckpoint() (checkpoint, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, checkpointFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return checkpoint{}, nil
+	}
+	if err != nil {
+		return checkpoint{}, fmt.Errorf("read spool checkpoint: %w", err)
+	}
+	var cp checkpoint
+	var damaged int
+	if n, err := fmt.Sscanf(string(data), "%d %d %d", &cp.seq, &cp.offset, &damaged); n != 3 {
+		log.Printf("Ignoring invalid spool checkpoint %q: %v", strings.TrimSpace(string(data)), err)
+		return checkpoint{}, nil
+	}
+	cp.damaged = damaged != 0
+	return cp, nil
+}
+
+// writeCheckpoint атомарно сохраняет прогресс воспроизведения через временный файл
+func (s *Spool) writeCheckpoint(cp checkpoint) error {
+	damaged := 0
+	if cp.damaged {
+		damaged = 1
+	}
+	path := filepath.Join(s.dir, checkpointFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(fmt.Sprintf("%d %d %d\n", cp.seq, cp.offset, damaged)), 0o644); err != nil {
+		return fmt.Errorf("write spool checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("write spool checkpoint: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,366 @@
+package spool
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func appendAll(t *testing.T, s *Spool, records ...string) {
+	t.Helper()
+	for _, record := range records {
+		if err := s.Append([]byte(record)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func replayAll(t *testing.T, s *Spool) []string {
+	t.Helper()
+	var got []string
+	if err := s.Replay(func(record []byte) error {
+		got = append(got, string(record))
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func files(t *testing.T, dir, pattern string) []string {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(dir, pattern))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return matches
+}
+
+// recordOffset смещение n-й записи в сегменте из записей указанных длин
+func recordOffset(lengths []int, n int) int64 {
+	var offset int64
+	for _, length := range lengths[:n] {
+		offset += int64(headerSize + length)
+	}
+	return offset
+}
+
+func TestReplayAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendAll(t, s, "a", "b", "c")
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = Open(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Pending() {
+		t.Fatal("expected pending records after restart")
+	}
+	if got, want := replayAll(t, s), []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("replayed %v, want %v", got, want)
+	}
+	if s.Pending() {
+		t.Fatal("expected no pending records after replay")
+	}
+	if left := files(t, dir, "*"); len(left) != 0 {
+		t.Fatalf("files left after replay: %v", left)
+	}
+}
+
+func TestReplayResumesFromCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Маленький сегмент: записи разойдутся по нескольким сегментам
+	appendAll(t, s, "first", "second", "third", "fourth", "fifth")
+
+	errDown := errors.New("database is down")
+	var got []string
+	err = s.Replay(func(record []byte) error {
+		if string(record) == "fourth" {
+			return errDown
+		}
+		got = append(got, string(record))
+		return nil
+	})
+	if !errors.Is(err, errDown) {
+		t.Fatalf("Replay error = %v, want %v", err, errDown)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = Open(dir, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, replayAll(t, s)...)
+	if want := []string{"first", "second", "third", "fourth", "fifth"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("replayed %v, want %v", got, want)
+	}
+}
+
+func TestReplayCorruptRecords(t *testing.T) {
+	records := []string{"alpha", "bravo", "charlie", "delta"}
+	lengths := []int{5, 5, 7, 5}
+	tests := []struct {
+		name        string
+		damage      func(data []byte) []byte
+		want        []string
+		wantCorrupt bool
+	}{
+		{
+			name: "torn tail after crash",
+			damage: func(data []byte) []byte {
+				return append(data, 0, 0, 0, 9, 1, 2)
+			},
+			want: records,
+		},
+		{
+			name: "torn payload after crash",
+			damage: func(data []byte) []byte {
+				return data[:len(data)-2]
+			},
+			want: records[:3],
+		},
+		{
+			name: "checksum mismatch mid-segment",
+			damage: func(data []byte) []byte {
+				data[recordOffset(lengths, 1)+headerSize] ^= 0xff
+				return data
+			},
+			want:        []string{"alpha", "charlie", "delta"},
+			wantCorrupt: true,
+		},
+		{
+			name: "invalid length mid-segment",
+			damage: func(data []byte) []byte {
+				binary.BigEndian.PutUint32(data[recordOffset(lengths, 2):], maxRecordSize+1)
+				return data
+			},
+			want:        []string{"alpha", "bravo"},
+			wantCorrupt: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			s, err := Open(dir, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			appendAll(t, s, records...)
+			if err := s.Close(); err != nil {
+				t.Fatal(err)
+			}
+			path := s.segmentPath(1)
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, tt.damage(data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			s, err = Open(dir, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := replayAll(t, s); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("replayed %v, want %v", got, tt.want)
+			}
+			if left := files(t, dir, "*"+segmentExt); len(left) != 0 {
+				t.Fatalf("segments left after replay: %v", left)
+			}
+			corrupt := files(t, dir, "*"+corruptExt)
+			if tt.wantCorrupt != (len(corrupt) == 1) {
+				t.Fatalf("corrupt segments = %v, want kept: %v", corrupt, tt.wantCorrupt)
+			}
+			if !tt.wantCorrupt {
+				return
+			}
+
+			// Номер отложенного сегмента не переиспользуется
+			s, err = Open(dir, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			appendAll(t, s, "echo")
+			if got, want := replayAll(t, s), []string{"echo"}; !reflect.DeepEqual(got, want) {
+				t.Fatalf("replayed %v, want %v", got, want)
+			}
+			if kept := files(t, dir, "*"+corruptExt); !reflect.DeepEqual(kept, corrupt) {
+				t.Fatalf("corrupt segments = %v, want %v", kept, corrupt)
+			}
+		})
+	}
+}
+
+func TestReplayKeepsCorruptSegmentAcrossRestart(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendAll(t, s, "alpha", "bravo", "charlie")
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	path := s.segmentPath(1)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[headerSize] ^= 0xff
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Воспроизведение прерывается после поврежденной записи, признак повреждения сохраняется в checkpoint
+	errDown := errors.New("database is down")
+	err = s.Replay(func(record []byte) error {
+		if string(record) == "charlie" {
+			return errDown
+		}
+		return nil
+	})
+	if !errors.Is(err, errDown) {
+		t.Fatalf("Replay error = %v, want %v", err, errDown)
+	}
+
+	s, err = Open(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := replayAll(t, s), []string{"charlie"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("replayed %v, want %v", got, want)
+	}
+	if corrupt := files(t, dir, "*"+corruptExt); len(corrupt) != 1 {
+		t.Fatalf("corrupt segments = %v, want one", corrupt)
+	}
+}
+
+func TestAppendDiscardsTornWrite(t *testing.T) {
+	tests := []struct {
+		name   string
+		before []string
+	}{
+		{name: "first record", before: nil},
+		{name: "after records", before: []string{"alpha", "bravo"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Open(t.TempDir(), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			appendAll(t, s, tt.before...)
+			if s.active == nil {
+				f, err := os.OpenFile(s.segmentPath(s.nextSeq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+				if err != nil {
+					t.Fatal(err)
+				}
+				s.active = f
+				s.nextSeq++
+			}
+			// Запись оборвалась на середине заголовка
+			if _, err := s.active.Write([]byte{0, 0, 0, 5, 1}); err != nil {
+				t.Fatal(err)
+			}
+			s.discardTail()
+			info, err := s.active.Stat()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Size() != s.activeSize {
+				t.Fatalf("segment size after discard = %d, want %d", info.Size(), s.activeSize)
+			}
+			if len(tt.before) == 0 && s.Pending() {
+				t.Fatal("empty active segment reported as pending")
+			}
+
+			appendAll(t, s, "charlie")
+			want := append(append([]string(nil), tt.before...), "charlie")
+			if got := replayAll(t, s); !reflect.DeepEqual(got, want) {
+				t.Fatalf("replayed %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestReplayRotatesOnlyWithData(t *testing.T) {
+	s, err := Open(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(s.segmentPath(s.nextSeq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.active = f
+	s.nextSeq++
+
+	for i := 0; i < 3; i++ {
+		if got := replayAll(t, s); len(got) != 0 {
+			t.Fatalf("replayed %v from empty spool", got)
+		}
+	}
+	if s.active != f || s.nextSeq != 2 {
+		t.Fatalf("empty active segment was rotated: active %v, next seq %d", s.active, s.nextSeq)
+	}
+
+	appendAll(t, s, "alpha")
+	if got, want := replayAll(t, s), []string{"alpha"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("replayed %v, want %v", got, want)
+	}
+	if s.active != nil {
+		t.Fatal("active segment with data was not rotated before replay")
+	}
+}
+
+func TestReadCheckpoint(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want checkpoint
+	}{
+		{name: "valid", data: "3 128 0\n", want: checkpoint{seq: 3, offset: 128}},
+		{name: "damaged", data: "3 128 1\n", want: checkpoint{seq: 3, offset: 128, damaged: true}},
+		// Без признака повреждения нельзя решить, отложить ли сегмент, поэтому checkpoint не принимается
+		{name: "two fields", data: "3 128\n", want: checkpoint{}},
+		{name: "garbage", data: "not a checkpoint", want: checkpoint{}},
+		{name: "empty", data: "", want: checkpoint{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, checkpointFile), []byte(tt.data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			s, err := Open(dir, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+			got, err := s.readCheckpoint()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("readCheckpoint() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -8,8 +8,20 @@ import (
 	"time"
 )
 
+// DefaultCopyThreshold размер пакета, начиная с которого вставка через COPY быстрее INSERT ... SELECT unnest
+const DefaultCopyThreshold = 500
+
+// Политики разрешения конфликтов при вставке цены с уже сохраненным ключом (coin, quote, source, timestamp)
+const (
+	ConflictIgnore            = "ignore"             // оставить сохраненную цену
+	ConflictOverwrite         = "overwrite"          // заменить новой ценой
+	ConflictHighestConfidence = "highest_confidence" // заменить, если у новой цены выше достоверность
+)
+
 type DataBase struct {
-	Psql postgresql.Postgreser
+	Psql           postgresql.Postgreser
+	CopyThreshold  int    // размер пакета, начиная с которого пакетная вставка идет через COPY
+	ConflictPolicy string // политика разрешения конфликтов при вставке цен
 }
 
 func New(cfgPostgres *types.ConfigPostgres, cfgConn *types.ConfigConnDB) (*DataBase, error) {
@@ -28,7 +40,23 @@ func New(cfgPostgres *types.ConfigPostgres, cfgConn *types.ConfigConnDB) (*DataB
 		return nil, fmt.Errorf("postgres: %v", err)
 	}
 
+	conflictPolicy := cfgConn.CfgDBConflictPolicy
+	switch conflictPolicy {
+	case "":
+		conflictPolicy = ConflictHighestConfidence
+	case ConflictIgnore, ConflictOverwrite, ConflictHighestConfidence:
+	default:
+		return nil, fmt.Errorf("unknown DB_CONFLICT_POLICY %q", conflictPolicy)
+	}
+
+	copyThreshold := cfgConn.CfgDBCopyThreshold
+	if copyThreshold <= 0 {
+		copyThreshold = DefaultCopyThreshold
+	}
+
 	return &DataBase{
-		Psql: psql,
+		Psql:           psql,
+		CopyThreshold:  copyThreshold,
+		ConflictPolicy: conflictPolicy,
 	}, nil
 }
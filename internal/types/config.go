@@ -13,9 +13,11 @@ type ConfigPostgres struct {
 
 // ConfigConnDB конфигурация подключения к БД
 type ConfigConnDB struct {
-	CfgDBMaxConn      int `mapstructure:"DB_MAX_CONN"`
-	CfgDBConnIdleTime int `mapstructure:"DB_CONN_IDLE_TIME"`
-	CfgDBConnLifeTime int `mapstructure:"DB_CONN_LIFE_TIME"`
+	CfgDBMaxConn        int    `mapstructure:"DB_MAX_CONN"`
+	CfgDBConnIdleTime   int    `mapstructure:"DB_CONN_IDLE_TIME"`
+	CfgDBConnLifeTime   int    `mapstructure:"DB_CONN_LIFE_TIME"`
+	CfgDBCopyThreshold  int    `mapstructure:"DB_COPY_THRESHOLD"`  // размер пакета, начиная с которого цены пишутся через COPY
+	CfgDBConflictPolicy string `mapstructure:"DB_CONFLICT_POLICY"` // ignore, overwrite или highest_confidence
 }
 
 // ConfigHTTPServer конфигурация HTTP сервера
@@ -33,12 +35,109 @@ type ConfigAPIClient struct {
 	Timeout      int    `mapstructure:"API_TIMEOUT"`
 	MaxRetries   int    `mapstructure:"API_MAX_RETRIES"`
 	RetryBackoff int    `mapstructure:"API_RETRY_BACKOFF"`
+
+	RecordMode  string `mapstructure:"API_RECORD_MODE"`  // record или replay; пусто - обычная работа
+	FixturesDir string `mapstructure:"API_FIXTURES_DIR"` // каталог файлов записанных обменов
+	ReplayMatch string `mapstructure:"API_REPLAY_MATCH"` // key или order
+}
+
+// ConfigProviders конфигурация поставщиков цен
+type ConfigProviders struct {
+	Providers               string `mapstructure:"PRICE_PROVIDERS"`            // список через запятую в порядке приоритета
+	QuoteCurrencies         string `mapstructure:"QUOTE_CURRENCIES"`           // валюты котировки через запятую: usd,eur,rub,btc
+	CoinGeckoAPIKey         string `mapstructure:"COINGECKO_API_KEY"`          // ключ API CoinGecko
+	CoinGeckoPlan           string `mapstructure:"COINGECKO_PLAN"`             // demo или pro; пусто - публичный API без ключа
+	CoinGeckoCallsPerMinute int    `mapstructure:"COINGECKO_CALLS_PER_MINUTE"` // лимит запросов в минуту по тарифу
+	CoinGeckoChunkSize      int    `mapstructure:"COINGECKO_CHUNK_SIZE"`       // максимум монет в одном запросе
+	CoinGeckoChunkWorkers   int    `mapstructure:"COINGECKO_CHUNK_WORKERS"`    // параллельных запросов по частям списка
+
+	BinanceBaseURL string `mapstructure:"BINANCE_BASE_URL"` // адрес REST API Binance
+	BinanceSymbols string `mapstructure:"BINANCE_SYMBOLS"`  // соответствие монет символам: bitcoin:BTCUSDT,ethereum:ETHUSDT
+
+	CoinbaseBaseURL  string `mapstructure:"COINBASE_BASE_URL"` // адрес REST API Coinbase Exchange
+	CoinbaseProducts string `mapstructure:"COINBASE_PRODUCTS"` // соответствие монет продуктам: bitcoin:BTC-USD
+	KrakenBaseURL    string `mapstructure:"KRAKEN_BASE_URL"`   // адрес REST API Kraken
+	KrakenPairs      string `mapstructure:"KRAKEN_PAIRS"`      // соответствие монет парам: bitcoin:XXBTZUSD
+
+	BreakerFailureThreshold  int `mapstructure:"BREAKER_FAILURE_THRESHOLD"`   // подряд неудачных запросов до размыкания
+	BreakerOpenTimeout       int `mapstructure:"BREAKER_OPEN_TIMEOUT"`        // в секундах
+	BreakerHalfOpenSuccesses int `mapstructure:"BREAKER_HALF_OPEN_SUCCESSES"` // успешных пробных запросов до замыкания
+
+	SyntheticModel        string  `mapstructure:"SYNTHETIC_MODEL"`         // gbm или random_walk
+	SyntheticSeed         int64   `mapstructure:"SYNTHETIC_SEED"`          // зерно генератора; 0 - случайное
+	SyntheticVolatility   float64 `mapstructure:"SYNTHETIC_VOLATILITY"`    // годовая волатильность, доля
+	SyntheticDrift        float64 `mapstructure:"SYNTHETIC_DRIFT"`         // годовой дрейф, доля
+	SyntheticStartPrice   float64 `mapstructure:"SYNTHETIC_START_PRICE"`   // начальная цена монет
+	SyntheticTickInterval int     `mapstructure:"SYNTHETIC_TICK_INTERVAL"` // в миллисекундах, не меньше 1000
+	SyntheticCoins        int     `mapstructure:"SYNTHETIC_COINS"`         // дополнительных монет synthetic-N в потоке
+
+	StreamProviders  string `mapstructure:"STREAM_PROVIDERS"`   // поставщики потоковых котировок через запятую
+	BinanceStreamURL string `mapstructure:"BINANCE_STREAM_URL"` // адрес WebSocket API Binance
+}
+
+// ConfigConsensus конфигурация расчета консенсусной цены по нескольким поставщикам
+type ConfigConsensus struct {
+	Method       string  `mapstructure:"CONSENSUS_METHOD"`        // median, trimmed_mean или weighted; пусто - без консенсуса
+	MaxDeviation float64 `mapstructure:"CONSENSUS_MAX_DEVIATION"` // допустимое отклонение от медианы, %
+	Trim         float64 `mapstructure:"CONSENSUS_TRIM"`          // доля значений, отбрасываемых с каждого края для trimmed_mean
+	Weights      string  `mapstructure:"CONSENSUS_WEIGHTS"`       // веса источников: binance:3,coingecko:1
+	MinSources   int     `mapstructure:"CONSENSUS_MIN_SOURCES"`   // минимум источников для расчета
 }
 
 // ConfigTasks конфигурация интервалов и батчинга PostgreSQL
 type ConfigTasks struct {
-	FetchInterval int `mapstructure:"FETCH_INTERVAL"`
-	BatchInterval int `mapstructure:"BATCH_INTERVAL"`
+	FetchInterval     int    `mapstructure:"FETCH_INTERVAL"`         // интервал опроса монет без собственного расписания, мс
+	FetchTiers        string `mapstructure:"FETCH_TIERS"`            // интервалы опроса по уровням в миллисекундах: high:10000,low:600000
+	FetchSchedule     string `mapstructure:"FETCH_SCHEDULE"`         // выражение cron для монет без собственного расписания, заменяет FETCH_INTERVAL
+	SnapshotSchedule  string `mapstructure:"SNAPSHOT_SCHEDULE"`      // выражение cron для одновременного опроса всех монет, например 0 0 * * *
+	AlignTimestamps   bool   `mapstructure:"FETCH_ALIGN_TIMESTAMPS"` // сохранять цены со временем слота расписания
+	BatchInterval     int    `mapstructure:"BATCH_INTERVAL"`         // максимальный возраст пакета цен, мс
+	BatchSize         int    `mapstructure:"BATCH_SIZE"`             // максимальный размер пакета цен
+	BatchMaxRetries   int    `mapstructure:"BATCH_MAX_RETRIES"`      // попыток записи пакета, после которых он отбрасывается
+	BatchFlushTimeout int    `mapstructure:"BATCH_FLUSH_TIMEOUT"`    // время на запись буфера при остановке, мс
+	IngestMode        string `mapstructure:"INGEST_MODE"`            // poll, stream или both
+}
+
+type ConfigSpool struct {
+	Dir         string `mapstructure:"SPOOL_DIR"`          // каталог журнала цен на время недоступности БД; пусто - журнал выключен
+	SegmentSize int    `mapstructure:"SPOOL_SEGMENT_SIZE"` // размер сегмента журнала, МБ
+}
+
+type ConfigAdaptive struct {
+	Enabled     bool    `mapstructure:"ADAPTIVE_POLLING"`      // подстраивать интервал опроса монет без собственного расписания под волатильность
+	MinInterval int     `mapstructure:"ADAPTIVE_MIN_INTERVAL"` // минимальный интервал опроса, мс
+	MaxInterval int     `mapstructure:"ADAPTIVE_MAX_INTERVAL"` // максимальный интервал опроса, мс
+	Threshold   float64 `mapstructure:"ADAPTIVE_THRESHOLD"`    // изменение цены за окно, %, при котором опрос учащается
+	Window      int     `mapstructure:"ADAPTIVE_WINDOW"`       // окно оценки изменения цены, минуты
+	Budget      float64 `mapstructure:"ADAPTIVE_BUDGET"`       // общий бюджет опросов монет в минуту, 0 - без ограничения
+}
+
+// ConfigBackfill конфигурация загрузки истории цен
+type ConfigBackfill struct {
+	PageDays  int  `mapstructure:"BACKFILL_PAGE_DAYS"`   // длина интервала одного запроса, дней (до 90 - почасовые точки)
+	OnAdd     bool `mapstructure:"BACKFILL_ON_ADD"`      // загружать историю при добавлении валюты
+	OnAddDays int  `mapstructure:"BACKFILL_ON_ADD_DAYS"` // глубина истории при добавлении, дней
+}
+
+// ConfigCandles конфигурация получения свечей OHLC
+type ConfigCandles struct {
+	Provider      string `mapstructure:"CANDLE_PROVIDER"`       // binance или coingecko
+	Intervals     string `mapstructure:"CANDLE_INTERVALS"`      // интервалы свечей через запятую; пусто - свечи не собираются
+	Quotes        string `mapstructure:"CANDLE_QUOTES"`         // валюты котировки свечей через запятую, по умолчанию usd
+	FetchInterval int    `mapstructure:"CANDLE_FETCH_INTERVAL"` // в миллисекундах
+}
+
+// ConfigCoins конфигурация справочника монет
+type ConfigCoins struct {
+	RefreshInterval int `mapstructure:"COIN_LIST_REFRESH_INTERVAL"` // в минутах
+}
+
+// ConfigFX конфигурация получения курсов фиатных валют
+type ConfigFX struct {
+	Providers     string `mapstructure:"FX_PROVIDERS"`      // ecb, cbr через запятую; пусто - курсы не загружаются
+	ECBURL        string `mapstructure:"FX_ECB_URL"`        // адрес ежедневных курсов ЕЦБ
+	CBRURL        string `mapstructure:"FX_CBR_URL"`        // адрес ежедневных курсов ЦБ РФ
+	FetchInterval int    `mapstructure:"FX_FETCH_INTERVAL"` // в минутах
 }
 
 // ConfigApp конфигурация всего приложения
@@ -47,5 +146,13 @@ type ConfigApp struct {
 	ConnDB     ConfigConnDB     `mapstructure:"db"`
 	HTTPServer ConfigHTTPServer `mapstructure:"http"`
 	APIClient  ConfigAPIClient  `mapstructure:"api"`
+	Providers  ConfigProviders  `mapstructure:"providers"`
+	Consensus  ConfigConsensus  `mapstructure:"consensus"`
 	Tasks      ConfigTasks      `mapstructure:"tasks"`
+	Adaptive   ConfigAdaptive   `mapstructure:"adaptive"`
+	Spool      ConfigSpool      `mapstructure:"spool"`
+	Backfill   ConfigBackfill   `mapstructure:"backfill"`
+	Candles    ConfigCandles    `mapstructure:"candles"`
+	Coins      ConfigCoins      `mapstructure:"coins"`
+	FX         ConfigFX         `mapstructure:"fx"`
 }
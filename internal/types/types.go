@@ -1,19 +1,169 @@
 package types
 
+// DefaultQuote валюта котировки по умолчанию
+const DefaultQuote = "usd"
+
 // CurrencyPrice содержит информацию по монете
 type CurrencyPrice struct {
 	Coin      string  `json:"coin"`
+	Quote     string  `json:"quote"` // Валюта котировки
+	Price     float64 `json:"price"`
+	Timestamp int64   `json:"timestamp"`
+	Source    string  `json:"source"`        // Поставщик цены либо consensus
+	Raw       bool    `json:"raw,omitempty"` // Исходная котировка источника, участвовавшая в консенсусе
+
+	MarketCap *float64 `json:"market_cap,omitempty" db:"market_cap"` // Рыночная капитализация
+	Volume24h *float64 `json:"volume_24h,omitempty" db:"volume_24h"` // Объем торгов за 24 часа
+	Change24h *float64 `json:"change_24h,omitempty" db:"change_24h"` // Изменение цены за 24 часа, %
+
+	Confidence *float64 `json:"confidence,omitempty"` // Достоверность цены от 0 до 1 для политики конфликтов highest_confidence
+
+	FXRate *float64 `json:"fx_rate,omitempty" db:"-"` // Курс USD, по которому цена пересчитана в валюту котировки
+}
+
+// Quote котировка монеты, полученная от поставщика цен
+type Quote struct {
+	Coin      string  `json:"coin"`
+	Quote     string  `json:"quote"` // Валюта котировки
 	Price     float64 `json:"price"`
 	Timestamp int64   `json:"timestamp"`
+	Source    string  `json:"source"`
+
+	MarketCap *float64 `json:"market_cap,omitempty"` // Рыночная капитализация
+	Volume24h *float64 `json:"volume_24h,omitempty"` // Объем торгов за 24 часа в валюте котировки
+	Change24h *float64 `json:"change_24h,omitempty"` // Изменение цены за 24 часа, %
+}
+
+// Candle свеча OHLC монеты за интервал
+type Candle struct {
+	Coin     string   `json:"coin"`
+	Quote    string   `json:"quote"`    // Валюта котировки
+	Interval string   `json:"interval"` // Длительность свечи: 1m, 1h, 4h, 1d
+	OpenTime int64    `json:"open_time" db:"open_time"`
+	Open     float64  `json:"open"`
+	High     float64  `json:"high"`
+	Low      float64  `json:"low"`
+	Close    float64  `json:"close"`
+	Volume   *float64 `json:"volume,omitempty"` // Объем в валюте котировки
+	Source   string   `json:"source"`
+}
+
+// Coin монета из справочника поставщика
+type Coin struct {
+	ID     string `json:"id"`     // Идентификатор CoinGecko
+	Symbol string `json:"symbol"` // Тикер
+	Name   string `json:"name"`
+}
+
+// CoinAlias пользовательский псевдоним монеты
+type CoinAlias struct {
+	Alias string `json:"alias" binding:"required"` // Тикер, название или иное написание, регистр не учитывается
+	Coin  string `json:"coin" binding:"required"`  // Идентификатор CoinGecko
+}
+
+// RemoveAliasRequest запрос на удаление псевдонима
+type RemoveAliasRequest struct {
+	Alias string `json:"alias" binding:"required"`
+}
+
+// AmbiguousCoinResponse ответ на неоднозначный тикер или название монеты
+type AmbiguousCoinResponse struct {
+	Error      string `json:"error"`
+	Candidates []Coin `json:"candidates"` // Монеты, подходящие под введенное значение
+}
+
+// FXRate курс фиатной валюты: 1 Base = Rate Currency
+type FXRate struct {
+	Base      string  `json:"base"`
+	Currency  string  `json:"currency"`
+	Rate      float64 `json:"rate"`
+	Timestamp int64   `json:"timestamp"` // Дата курса, unix-секунды на начало суток UTC
+	Source    string  `json:"source"`
+}
+
+// ProviderStatus состояние автоматического выключателя поставщика цен
+type ProviderStatus struct {
+	Name      string `json:"name"`
+	State     string `json:"state"` // closed, open или half-open
+	Failures  int    `json:"failures"`
+	OpenedAt  *int64 `json:"opened_at,omitempty"`
+	LastError string `json:"last_error,omitempty"`
 }
 
 // AddCurrencyRequest содержит список использующзихся монет
 type AddCurrencyRequest struct {
-	Coin string `json:"coin" binding:"required"`
+	Coin     string `json:"coin" binding:"required"`
+	Interval *int64 `json:"interval"` // Интервал опроса монеты в миллисекундах, имеет приоритет над tier
+	Tier     string `json:"tier"`     // Уровень приоритета из FETCH_TIERS; без interval и tier используется FETCH_INTERVAL
+}
+
+// WatchedCurrency наблюдаемая монета с расписанием опроса
+type WatchedCurrency struct {
+	Coin          string `json:"coin"`
+	FetchInterval *int64 `json:"fetch_interval,omitempty" db:"fetch_interval"` // в миллисекундах
+	Tier          string `json:"tier,omitempty"`
+}
+
+// PriceRange диапазон цен монеты в одной валюте котировки за окно
+type PriceRange struct {
+	Coin  string
+	Quote string
+	Low   float64
+	High  float64
+	Count int64
+}
+
+// UnknownCoinResponse ответ на добавление монеты, которой нет в справочнике
+type UnknownCoinResponse struct {
+	Error       string   `json:"error"`
+	Suggestions []string `json:"suggestions"` // Близкие по написанию идентификаторы
 }
 
 // PriceRequest запрос на получение цены
 type PriceRequest struct {
 	Coin      string `json:"coin" binding:"required"`
+	Quote     string `json:"quote"` // Валюта котировки, по умолчанию usd
 	Timestamp *int64 `json:"timestamp"`
+	Source    string `json:"source"` // Источник цены; по умолчанию итоговая цена (консенсусная или единственного источника)
+}
+
+// CandlesRequest запрос на получение свечей
+type CandlesRequest struct {
+	Coin     string `json:"coin" binding:"required"`
+	Quote    string `json:"quote"`                       // Валюта котировки, по умолчанию usd
+	Interval string `json:"interval" binding:"required"` // Длительность свечи
+	From     int64  `json:"from" binding:"required"`     // Начало интервала, unix-секунды
+	To       *int64 `json:"to"`                          // Конец интервала, по умолчанию текущее время
+	Source   string `json:"source"`                      // Источник свечей, по умолчанию любой
+}
+
+// Статусы задания загрузки истории
+const (
+	BackfillPending = "pending" // ожидает выполнения
+	BackfillRunning = "running" // выполняется
+	BackfillDone    = "done"    // загружен весь интервал
+	BackfillFailed  = "failed"  // остановлено из-за ошибки
+)
+
+// BackfillJob задание загрузки истории цен монеты
+type BackfillJob struct {
+	ID        int64  `json:"id"`
+	Coin      string `json:"coin"`
+	Quote     string `json:"quote"`
+	FromTS    int64  `json:"from" db:"from_ts"`     // Начало интервала, unix-секунды
+	ToTS      int64  `json:"to" db:"to_ts"`         // Конец интервала, unix-секунды
+	CursorTS  int64  `json:"cursor" db:"cursor_ts"` // Время, до которого история уже загружена
+	Status    string `json:"status"`
+	Stored    int64  `json:"stored"` // Сохранено новых точек
+	LastError string `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt int64  `json:"created_at" db:"created_at"`
+	UpdatedAt int64  `json:"updated_at" db:"updated_at"`
+}
+
+// BackfillRequest запрос на загрузку истории цен
+type BackfillRequest struct {
+	Coin  string `json:"coin" binding:"required"`
+	Quote string `json:"quote"`                   // Валюта котировки, по умолчанию usd
+	From  int64  `json:"from" binding:"required"` // Начало интервала, unix-секунды
+	To    *int64 `json:"to"`                      // Конец интервала, по умолчанию текущее время
 }
@@ -0,0 +1,105 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{name: "empty", expr: ""},
+		{name: "too few fields", expr: "* * * *"},
+		{name: "too many fields", expr: "* * * * * *"},
+		{name: "minute out of range", expr: "60 * * * *"},
+		{name: "hour out of range", expr: "0 24 * * *"},
+		{name: "day of month zero", expr: "0 0 0 * *"},
+		{name: "month out of range", expr: "0 0 1 13 *"},
+		{name: "day of week out of range", expr: "0 0 * * 8"},
+		{name: "zero step", expr: "*/0 * * * *"},
+		{name: "bad step", expr: "*/x * * * *"},
+		{name: "reversed range", expr: "0 10-5 * * *"},
+		{name: "unknown name", expr: "0 0 * foo *"},
+		{name: "unknown descriptor", expr: "@fortnightly"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if s, err := Parse(tt.expr); err == nil {
+				t.Fatalf("Parse(%q) = %v, expected error", tt.expr, s)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	// 10 мая 2024 года — пятница
+	from := time.Date(2024, 5, 10, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{name: "every minute", expr: "* * * * *", from: from, want: time.Date(2024, 5, 10, 10, 8, 0, 0, time.UTC)},
+		{name: "strictly after", expr: "8 * * * *", from: time.Date(2024, 5, 10, 10, 8, 0, 0, time.UTC), want: time.Date(2024, 5, 10, 11, 8, 0, 0, time.UTC)},
+		{name: "step", expr: "*/5 * * * *", from: from, want: time.Date(2024, 5, 10, 10, 10, 0, 0, time.UTC)},
+		{name: "step from value", expr: "3/20 * * * *", from: from, want: time.Date(2024, 5, 10, 10, 23, 0, 0, time.UTC)},
+		{name: "list", expr: "0 9,18 * * *", from: from, want: time.Date(2024, 5, 10, 18, 0, 0, 0, time.UTC)},
+		{name: "range with step", expr: "0 8-20/4 * * *", from: from, want: time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)},
+		{name: "month names", expr: "0 0 1 jan,JUL *", from: from, want: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "weekday names", expr: "30 9 * * mon-wed", from: from, want: time.Date(2024, 5, 13, 9, 30, 0, 0, time.UTC)},
+		{name: "sunday as 0", expr: "0 12 * * 0", from: from, want: time.Date(2024, 5, 12, 12, 0, 0, 0, time.UTC)},
+		{name: "sunday as 7", expr: "0 12 * * 7", from: from, want: time.Date(2024, 5, 12, 12, 0, 0, 0, time.UTC)},
+		{name: "range ending in 7", expr: "0 12 * * 6-7", from: from, want: time.Date(2024, 5, 11, 12, 0, 0, 0, time.UTC)},
+		// День месяца и день недели заданы оба: достаточно совпадения одного из них
+		{name: "dom or dow matches dow first", expr: "0 0 20 * mon", from: from, want: time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC)},
+		{name: "dom or dow matches dom first", expr: "0 0 11 * mon", from: from, want: time.Date(2024, 5, 11, 0, 0, 0, 0, time.UTC)},
+		// Если одно из полей *, действует только другое
+		{name: "dom with star dow", expr: "0 0 20 * *", from: from, want: time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC)},
+		{name: "dow with star dom", expr: "0 0 * * mon", from: from, want: time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC)},
+		{name: "leap day", expr: "0 0 29 2 *", from: from, want: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{name: "february 30 never fires", expr: "0 0 30 2 *", from: from, want: time.Time{}},
+		{name: "hourly", expr: "@hourly", from: from, want: time.Date(2024, 5, 10, 11, 0, 0, 0, time.UTC)},
+		{name: "daily", expr: "@daily", from: from, want: time.Date(2024, 5, 11, 0, 0, 0, 0, time.UTC)},
+		{name: "weekly", expr: "@weekly", from: from, want: time.Date(2024, 5, 12, 0, 0, 0, 0, time.UTC)},
+		{name: "monthly", expr: "@Monthly", from: from, want: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "yearly", expr: "@yearly", from: from, want: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "month rollover", expr: "0 0 1 * *", from: time.Date(2024, 12, 31, 23, 59, 0, 0, time.UTC), want: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.Next(tt.from); !got.Equal(tt.want) {
+				t.Fatalf("Next(%s) = %s, want %s", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextKeepsLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+3", 3*60*60)
+	s, err := Parse("0 9 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := s.Next(time.Date(2024, 5, 10, 10, 0, 0, 0, loc))
+	want := time.Date(2024, 5, 11, 9, 0, 0, 0, loc)
+	if !got.Equal(want) || got.Location() != loc {
+		t.Fatalf("Next = %s, want %s", got, want)
+	}
+}
+
+func TestString(t *testing.T) {
+	s, err := Parse("  @daily ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.String() != "@daily" {
+		t.Fatalf("String() = %q, want %q", s.String(), "@daily")
+	}
+}
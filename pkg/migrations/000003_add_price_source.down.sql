@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_currency_source_timestamp;
+ALTER TABLE currency_prices DROP COLUMN IF EXISTS raw;
+ALTER TABLE currency_prices DROP COLUMN IF EXISTS source;
@@ -0,0 +1,8 @@
+-- До появления нескольких поставщиков все цены приходили из CoinGecko
+ALTER TABLE currency_prices ADD COLUMN IF NOT EXISTS source VARCHAR(32) NOT NULL DEFAULT 'coingecko';
+ALTER TABLE currency_prices ALTER COLUMN source DROP DEFAULT;
+
+-- raw = TRUE для исходных котировок источников, по которым считался консенсус
+ALTER TABLE currency_prices ADD COLUMN IF NOT EXISTS raw BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_currency_source_timestamp ON currency_prices(coin, source, timestamp);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_currency_quote_timestamp;
+CREATE INDEX IF NOT EXISTS idx_currency_timestamp ON currency_prices(coin, timestamp);
+ALTER TABLE currency_prices DROP COLUMN IF EXISTS quote;
@@ -0,0 +1,5 @@
+-- Все цены до появления нескольких валют котировки были в USD
+ALTER TABLE currency_prices ADD COLUMN IF NOT EXISTS quote VARCHAR(10) NOT NULL DEFAULT 'usd';
+
+DROP INDEX IF EXISTS idx_currency_timestamp;
+CREATE INDEX IF NOT EXISTS idx_currency_quote_timestamp ON currency_prices(coin, quote, timestamp);
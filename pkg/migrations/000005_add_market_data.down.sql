@@ -0,0 +1,3 @@
+ALTER TABLE currency_prices DROP COLUMN IF EXISTS change_24h;
+ALTER TABLE currency_prices DROP COLUMN IF EXISTS volume_24h;
+ALTER TABLE currency_prices DROP COLUMN IF EXISTS market_cap;
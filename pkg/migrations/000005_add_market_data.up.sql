@@ -0,0 +1,3 @@
+ALTER TABLE currency_prices ADD COLUMN IF NOT EXISTS market_cap DOUBLE PRECISION;
+ALTER TABLE currency_prices ADD COLUMN IF NOT EXISTS volume_24h DOUBLE PRECISION;
+ALTER TABLE currency_prices ADD COLUMN IF NOT EXISTS change_24h DOUBLE PRECISION;
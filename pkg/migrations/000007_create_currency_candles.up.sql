@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS currency_candles (
+    coin VARCHAR(100) NOT NULL,
+    quote VARCHAR(10) NOT NULL,
+    interval VARCHAR(8) NOT NULL,
+    open_time BIGINT NOT NULL,
+    source VARCHAR(32) NOT NULL,
+    open DOUBLE PRECISION NOT NULL,
+    high DOUBLE PRECISION NOT NULL,
+    low DOUBLE PRECISION NOT NULL,
+    close DOUBLE PRECISION NOT NULL,
+    volume DOUBLE PRECISION,
+    PRIMARY KEY (coin, quote, interval, open_time, source)
+);
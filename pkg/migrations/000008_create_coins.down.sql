@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS coins;
+
+-- Колонки coin не сужаются обратно до VARCHAR(10), чтобы не потерять уже сохраненные длинные идентификаторы
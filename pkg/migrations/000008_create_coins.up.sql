@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS coins (
+    id VARCHAR(100) PRIMARY KEY,
+    symbol VARCHAR(50) NOT NULL,
+    name TEXT NOT NULL,
+    updated_at BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_coins_symbol ON coins(symbol);
+
+-- Идентификаторы CoinGecko бывают длиннее 10 символов (wrapped-bitcoin, avalanche-2)
+ALTER TABLE watched_currencies ALTER COLUMN coin TYPE VARCHAR(100);
+ALTER TABLE currency_prices ALTER COLUMN coin TYPE VARCHAR(100);
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS coin_aliases (
+    alias VARCHAR(100) PRIMARY KEY,
+    coin VARCHAR(100) NOT NULL,
+    created_at BIGINT NOT NULL
+);
+
+-- Тикеры популярных монет, которые в справочнике CoinGecko повторяются у множества других токенов
+INSERT INTO coin_aliases (alias, coin, created_at) VALUES
+    ('btc', 'bitcoin', EXTRACT(EPOCH FROM NOW())::BIGINT),
+    ('eth', 'ethereum', EXTRACT(EPOCH FROM NOW())::BIGINT),
+    ('usdt', 'tether', EXTRACT(EPOCH FROM NOW())::BIGINT),
+    ('usdc', 'usd-coin', EXTRACT(EPOCH FROM NOW())::BIGINT),
+    ('bnb', 'binancecoin', EXTRACT(EPOCH FROM NOW())::BIGINT),
+    ('sol', 'solana', EXTRACT(EPOCH FROM NOW())::BIGINT),
+    ('xrp', 'ripple', EXTRACT(EPOCH FROM NOW())::BIGINT),
+    ('ada', 'cardano', EXTRACT(EPOCH FROM NOW())::BIGINT),
+    ('doge', 'dogecoin', EXTRACT(EPOCH FROM NOW())::BIGINT),
+    ('ton', 'the-open-network', EXTRACT(EPOCH FROM NOW())::BIGINT),
+    ('trx', 'tron', EXTRACT(EPOCH FROM NOW())::BIGINT),
+    ('dot', 'polkadot', EXTRACT(EPOCH FROM NOW())::BIGINT),
+    ('ltc', 'litecoin', EXTRACT(EPOCH FROM NOW())::BIGINT),
+    ('avax', 'avalanche-2', EXTRACT(EPOCH FROM NOW())::BIGINT)
+ON CONFLICT (alias) DO NOTHING;
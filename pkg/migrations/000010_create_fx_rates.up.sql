@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS fx_rates (
+    base VARCHAR(10) NOT NULL,
+    currency VARCHAR(10) NOT NULL,
+    rate DOUBLE PRECISION NOT NULL,
+    timestamp BIGINT NOT NULL,
+    source VARCHAR(32) NOT NULL,
+    PRIMARY KEY (base, currency, source, timestamp)
+);
+
+CREATE INDEX IF NOT EXISTS idx_fx_rates_currency_timestamp ON fx_rates(base, currency, timestamp);
@@ -0,0 +1,2 @@
+ALTER TABLE watched_currencies DROP COLUMN IF EXISTS tier;
+ALTER TABLE watched_currencies DROP COLUMN IF EXISTS fetch_interval;
@@ -0,0 +1,2 @@
+ALTER TABLE watched_currencies ADD COLUMN IF NOT EXISTS fetch_interval BIGINT;
+ALTER TABLE watched_currencies ADD COLUMN IF NOT EXISTS tier VARCHAR(32);
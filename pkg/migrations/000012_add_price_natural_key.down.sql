@@ -0,0 +1,2 @@
+ALTER TABLE currency_prices DROP CONSTRAINT IF EXISTS currency_prices_natural_key;
+ALTER TABLE currency_prices DROP COLUMN IF EXISTS confidence;
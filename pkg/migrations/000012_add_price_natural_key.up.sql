@@ -0,0 +1,16 @@
+-- Повторные попытки, воспроизведение журнала и загрузка истории создавали дубликаты; оставляем последнюю вставленную строку
+DELETE FROM currency_prices
+WHERE id IN (
+    SELECT id
+    FROM (
+        SELECT id, ROW_NUMBER() OVER (PARTITION BY coin, quote, source, timestamp ORDER BY id DESC) AS rn
+        FROM currency_prices
+    ) duplicates
+    WHERE rn > 1
+);
+
+-- Достоверность цены для политики highest_confidence; у старых строк не задана
+ALTER TABLE currency_prices ADD COLUMN IF NOT EXISTS confidence DOUBLE PRECISION;
+
+-- Цены одной монеты в разных валютах котировки за одно время различаются, поэтому quote входит в ключ
+ALTER TABLE currency_prices ADD CONSTRAINT currency_prices_natural_key UNIQUE (coin, quote, source, timestamp);